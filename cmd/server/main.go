@@ -13,6 +13,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"home-monitor/internal/audio"
 	"home-monitor/internal/capture"
 	"home-monitor/internal/config"
 	"home-monitor/internal/handler"
@@ -53,28 +54,29 @@ func main() {
 	streamManager := stream.NewStreamManager(captureManager, cfg.Stream)
 	storageManager := storage.NewStorageManager(captureManager, cfg.Storage)
 
+	// 初始化音频分析（电平计量）
+	audioManager := audio.NewManager(captureManager)
+
+	// 如果启用录像，之后添加的采集器（包括运行时通过 API 添加的）都会录像
+	if cfg.Storage.Enabled {
+		captureManager.SetRecordingConfig(capture.RecordingConfig{
+			OutputPath:      cfg.Storage.Path,
+			SegmentDuration: cfg.Storage.GetSegmentDurationSeconds(),
+			Format:          cfg.Storage.Format,
+		})
+	}
+
+	// 主预览档位的 JPEG 质量与录像转码档位
+	captureManager.SetPreviewQuality(cfg.Preview.MJPEG.Quality)
+	captureManager.SetEncoderProfiles(cfg.Encoders)
+
 	// 添加采集器（每个摄像头一个）
 	for _, camCfg := range cfg.Cameras {
 		if !camCfg.Enabled {
 			continue
 		}
-
-		// 如果启用录像，使用带录制配置的采集器
-		if cfg.Storage.Enabled {
-			recCfg := capture.RecordingConfig{
-				OutputPath:      cfg.Storage.Path,
-				SegmentDuration: cfg.Storage.GetSegmentDurationSeconds(),
-				Format:          cfg.Storage.Format,
-			}
-			if _, err := captureManager.AddCapturerWithRecording(camCfg, recCfg); err != nil {
-				log.Printf("添加采集器 %s 失败: %v", camCfg.ID, err)
-				continue
-			}
-		} else {
-			if _, err := captureManager.AddCapturer(camCfg); err != nil {
-				log.Printf("添加采集器 %s 失败: %v", camCfg.ID, err)
-				continue
-			}
+		if _, err := captureManager.AddCapturer(camCfg); err != nil {
+			log.Printf("添加采集器 %s 失败: %v", camCfg.ID, err)
 		}
 	}
 
@@ -88,6 +90,9 @@ func main() {
 		log.Printf("启动流处理失败: %v", err)
 	}
 
+	// 开始音频电平计量
+	audioManager.StartAll()
+
 	// 录像功能由 FFmpeg segment 自动处理（在 capturer 启动时已经开始）
 	if cfg.Storage.Enabled {
 		log.Println("📹 录像功能已启用（FFmpeg segment 自动分段）")
@@ -99,8 +104,32 @@ func main() {
 	// 启动性能监控
 	perfMonitor := monitor.NewMonitor()
 	perfMonitor.SetThresholds(512, 1000) // 内存 512MB, Goroutine 1000
+	perfMonitor.SetCameraHealthProvider(func() []monitor.CameraHealth {
+		var health []monitor.CameraHealth
+		for _, c := range captureManager.GetAllCapturers() {
+			status := c.GetStatus()
+			health = append(health, monitor.CameraHealth{
+				ID:           c.GetID(),
+				Name:         c.GetName(),
+				Stalled:      status.Stalled,
+				LastFrameAge: time.Duration(status.LastFrameAge * float64(time.Second)),
+			})
+		}
+		return health
+	})
 	perfMonitor.Start(ctx)
 
+	// 音频检测事件与画面停滞一起进入告警列表
+	audioManager.AddEventListener(func(event audio.Event) {
+		perfMonitor.ReportAlert(monitor.Alert{
+			Time:     event.Time,
+			Type:     "audio_" + event.Type,
+			Message:  fmt.Sprintf("摄像头 %s 音频事件: %s", event.CameraID, event.Message),
+			Value:    fmt.Sprintf("%.1f dBFS", event.Level),
+			CameraID: event.CameraID,
+		})
+	})
+
 	// 设置 Gin
 	gin.SetMode(gin.ReleaseMode)
 
@@ -110,10 +139,10 @@ func main() {
 	var rtmpManager *rtmp.Manager
 
 	// 创建 RTMP 管理器
-	rtmpManager = rtmp.NewManager(ctx, captureManager, cfg.Cameras)
+	rtmpManager = rtmp.NewManager(ctx, captureManager, cfg.Encoders)
 
 	// 创建 HLS 输出管理器
-	hlsOutputManager := stream.NewHLSOutputManager(ctx, captureManager, cfg.Cameras, cfg.Stream)
+	hlsOutputManager := stream.NewHLSOutputManager(ctx, captureManager, cfg.Stream, cfg.Encoders)
 
 	// ===== 主服务（管理后台） =====
 	mainRouter := gin.Default()
@@ -130,6 +159,10 @@ func main() {
 		WebRTCPort:    cfg.Preview.WebRTC.Port,
 	})
 
+	// 运行时增删改摄像头时写回配置文件
+	h.SetConfigStore(config.NewStore(*configPath))
+	h.SetEncoderProfiles(cfg.Encoders)
+
 	handler.SetupRoutes(mainRouter, h, nil) // 主服务不需要 WebRTC handler
 
 	// 注册 RTMP API 路由
@@ -149,6 +182,22 @@ func main() {
 	// 提供 HLS 分片文件服务
 	mainRouter.Static("/hls", hlsOutputManager.GetOutputPath())
 
+	// 注册帧推送 API 路由（push 类型摄像头）
+	ingestHandler := handler.NewIngestHandler(captureManager)
+	ingestHandler.RegisterRoutes(mainRouter.Group("/api"))
+
+	// 注册摄像头 FFmpeg 日志 API 路由
+	logHandler := handler.NewLogHandler(captureManager)
+	logHandler.RegisterRoutes(mainRouter.Group("/api"))
+
+	// 注册音频电平 API 路由
+	audioHandler := handler.NewAudioHandler(audioManager, captureManager)
+	audioHandler.RegisterRoutes(mainRouter.Group("/api"))
+
+	// 注册设备发现 API 路由
+	deviceHandler := handler.NewDeviceHandler()
+	deviceHandler.RegisterRoutes(mainRouter.Group("/api"))
+
 	// 注册性能监控 API 路由
 	monitorHandler := handler.NewMonitorHandler(perfMonitor)
 	monitorHandler.RegisterRoutes(mainRouter.Group("/api"))
@@ -178,7 +227,6 @@ func main() {
 
 		mjpegHandler := handler.NewMJPEGHandler(
 			captureManager,
-			cfg.Preview.MJPEG.Quality,
 			cfg.Server.Port,
 			cfg.Preview.MJPEG.Port,
 		)
@@ -205,7 +253,7 @@ func main() {
 		webrtcRouter.Use(gin.Recovery())
 		webrtcRouter.Use(corsMiddleware()) // 允许跨域
 
-		webrtcServer = webrtc.NewServer(captureManager, cfg.Cameras, cfg.Preview.WebRTC.STUNServer)
+		webrtcServer = webrtc.NewServer(captureManager, cfg.Preview.WebRTC.STUNServer)
 		webrtcHandler := handler.NewWebRTCHandler(
 			webrtcServer,
 			cfg.Server.Port,
@@ -242,6 +290,7 @@ func main() {
 	// 停止所有组件
 	perfMonitor.Stop()         // 先停监控
 	hlsOutputManager.StopAll() // 停 HLS
+	audioManager.StopAll()
 	captureManager.StopAll()
 	streamManager.StopAll()
 	storageManager.StopAll()
@@ -0,0 +1,281 @@
+// Package audio 分析各摄像头采集器输出的 PCM 音频
+// 按 20ms 音频块计算 RMS/峰值电平（dBFS），保留最近的电平历史，供 API 查询和实时订阅（VU 表、麦克风检测）；
+// 按摄像头配置的检测器（阈值、持续声音、声音后安静）产生音频事件，通知事件监听者（告警列表等）。
+package audio
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"home-monitor/internal/capture"
+)
+
+// 每个摄像头保留的最近事件数
+const eventsLimit = 100
+
+// feed 对采集器音频的订阅
+type feed struct {
+	capturer       capture.AVCapturer
+	subscriptionID string
+	detectors      []*detector
+	done           chan struct{}
+}
+
+// EventListener 音频事件监听函数，在音频处理 goroutine 中同步调用，不应阻塞
+type EventListener func(Event)
+
+// Snapshot 摄像头的电平概况
+type Snapshot struct {
+	CameraID string  `json:"camera_id"`
+	Metering bool    `json:"metering"` // 是否正在计量（采集器运行且启用音频）
+	Current  *Level  `json:"current"`  // 最近一个音频块的电平，没有时为 null
+	Summary  Level   `json:"summary"`  // 历史窗口内的平均 RMS 和最大峰值
+	History  []Level `json:"history"`  // 历史窗口内每个音频块的电平，按时间排序
+}
+
+// Manager 音频分析管理器
+// 跟随采集器生命周期订阅音频，采集器重启期间电平历史和实时订阅者保持不变
+type Manager struct {
+	captureManager *capture.Manager
+	meters         map[string]*meter
+	feeds          map[string]*feed
+	events         map[string][]Event
+	started        bool
+	mutex          sync.Mutex
+
+	listeners     []EventListener
+	listenerMutex sync.RWMutex
+}
+
+// NewManager 创建音频分析管理器
+func NewManager(capManager *capture.Manager) *Manager {
+	m := &Manager{
+		captureManager: capManager,
+		meters:         make(map[string]*meter),
+		feeds:          make(map[string]*feed),
+		events:         make(map[string][]Event),
+	}
+	capManager.AddListener(m.handleCaptureEvent)
+	return m
+}
+
+// handleCaptureEvent 采集器停止前释放音频订阅，启动后重新订阅，移除后清理电平历史和事件
+func (m *Manager) handleCaptureEvent(event capture.Event) {
+	switch event.Type {
+	case capture.EventStopping:
+		m.detach(event.CameraID)
+	case capture.EventRemoved:
+		m.remove(event.CameraID)
+	case capture.EventStarted:
+		m.mutex.Lock()
+		started := m.started
+		m.mutex.Unlock()
+		if started {
+			m.attach(event.CameraID)
+		}
+	}
+}
+
+// AddEventListener 注册音频事件监听
+func (m *Manager) AddEventListener(l EventListener) {
+	m.listenerMutex.Lock()
+	defer m.listenerMutex.Unlock()
+	m.listeners = append(m.listeners, l)
+}
+
+// StartAll 开始分析所有已启用音频的采集器
+func (m *Manager) StartAll() {
+	m.mutex.Lock()
+	m.started = true
+	m.mutex.Unlock()
+
+	for _, c := range m.captureManager.GetAllCapturers() {
+		if c.IsRunning() {
+			m.attach(c.GetID())
+		}
+	}
+}
+
+// StopAll 停止所有分析
+func (m *Manager) StopAll() {
+	m.mutex.Lock()
+	m.started = false
+	ids := make([]string, 0, len(m.feeds))
+	for id := range m.feeds {
+		ids = append(ids, id)
+	}
+	m.mutex.Unlock()
+
+	for _, id := range ids {
+		m.detach(id)
+	}
+}
+
+// attach 订阅采集器的音频块，计量电平并运行检测器，未启用音频时跳过
+// 检测器按采集器当前配置创建，配置变更重启后重新创建
+func (m *Manager) attach(id string) {
+	capturer, err := m.captureManager.GetCapturer(id)
+	if err != nil || !capturer.HasAudio() {
+		return
+	}
+
+	m.mutex.Lock()
+	if _, exists := m.feeds[id]; exists {
+		m.mutex.Unlock()
+		return
+	}
+	meter, exists := m.meters[id]
+	if !exists {
+		meter = newMeter()
+		m.meters[id] = meter
+	}
+	f := &feed{
+		capturer:       capturer,
+		subscriptionID: fmt.Sprintf("audio_meter_%s_%d", id, time.Now().UnixNano()),
+		done:           make(chan struct{}),
+	}
+	for _, cfg := range capturer.GetConfig().Audio.Detectors {
+		f.detectors = append(f.detectors, newDetector(cfg))
+	}
+	m.feeds[id] = f
+	m.mutex.Unlock()
+
+	chunks := capturer.SubscribeAudioChunks(f.subscriptionID, capture.WithLabel("audio_meter"))
+	go func() {
+		for {
+			select {
+			case <-f.done:
+				return
+			case chunk, ok := <-chunks:
+				if !ok {
+					return
+				}
+				level := meter.process(chunk)
+				m.detect(id, f, level)
+			}
+		}
+	}()
+	log.Printf("🎚️ 开始音频电平计量: %s", id)
+}
+
+// detach 取消对采集器音频的订阅，保留电平历史
+func (m *Manager) detach(id string) {
+	m.mutex.Lock()
+	f, exists := m.feeds[id]
+	delete(m.feeds, id)
+	m.mutex.Unlock()
+
+	if !exists {
+		return
+	}
+	close(f.done)
+	f.capturer.UnsubscribeAudioChunks(f.subscriptionID)
+}
+
+// remove 取消订阅并删除摄像头的电平计量和事件记录
+// 仍在连接的实时订阅者不再收到电平，断开时取消订阅即可
+func (m *Manager) remove(id string) {
+	m.detach(id)
+
+	m.mutex.Lock()
+	delete(m.meters, id)
+	delete(m.events, id)
+	m.mutex.Unlock()
+}
+
+// detect 运行检测器；隐私模式下音频为静音占位，清除检测状态且不触发
+func (m *Manager) detect(id string, f *feed, level Level) {
+	if len(f.detectors) == 0 {
+		return
+	}
+	if f.capturer.PrivacyStatus().Active {
+		for _, d := range f.detectors {
+			d.reset()
+		}
+		return
+	}
+	for _, d := range f.detectors {
+		if event := d.feed(level); event != nil {
+			event.CameraID = id
+			m.emit(*event)
+		}
+	}
+}
+
+// emit 记录事件并通知监听者（告警列表负责输出日志）
+func (m *Manager) emit(event Event) {
+	m.mutex.Lock()
+	events := append(m.events[event.CameraID], event)
+	if len(events) > eventsLimit {
+		events = events[len(events)-eventsLimit:]
+	}
+	m.events[event.CameraID] = events
+	m.mutex.Unlock()
+
+	m.listenerMutex.RLock()
+	listeners := make([]EventListener, len(m.listeners))
+	copy(listeners, m.listeners)
+	m.listenerMutex.RUnlock()
+
+	for _, l := range listeners {
+		l(event)
+	}
+}
+
+// Events 获取摄像头最近的音频事件（倒序），limit <= 0 表示全部
+func (m *Manager) Events(id string, limit int) []Event {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	events := m.events[id]
+	if limit <= 0 || limit > len(events) {
+		limit = len(events)
+	}
+	result := make([]Event, limit)
+	for i := 0; i < limit; i++ {
+		result[i] = events[len(events)-1-i]
+	}
+	return result
+}
+
+// getMeter 获取摄像头的电平计量，不存在时创建（采集器尚未启动时也可订阅）
+func (m *Manager) getMeter(id string) *meter {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	meter, exists := m.meters[id]
+	if !exists {
+		meter = newMeter()
+		m.meters[id] = meter
+	}
+	return meter
+}
+
+// Snapshot 获取摄像头最近 window 内的电平（最长 10 秒）
+func (m *Manager) Snapshot(id string, window time.Duration) Snapshot {
+	window = min(window, historyDuration)
+	history := m.getMeter(id).snapshot(window)
+
+	m.mutex.Lock()
+	_, metering := m.feeds[id]
+	m.mutex.Unlock()
+
+	snapshot := Snapshot{
+		CameraID: id,
+		Metering: metering,
+		Summary:  Combine(history),
+		History:  history,
+	}
+	if len(history) > 0 {
+		current := history[len(history)-1]
+		snapshot.Current = &current
+	}
+	return snapshot
+}
+
+// Subscribe 实时订阅摄像头每个音频块的电平，返回的函数用于取消订阅
+func (m *Manager) Subscribe(id string) (<-chan Level, func()) {
+	return m.getMeter(id).subscribe()
+}
@@ -0,0 +1,40 @@
+package audio
+
+import (
+	"testing"
+	"time"
+
+	"home-monitor/internal/capture"
+	"home-monitor/internal/config"
+)
+
+// TestRemoveCapturerClearsState 移除摄像头后清理电平历史和事件，同 ID 重新添加时不显示旧数据
+func TestRemoveCapturerClearsState(t *testing.T) {
+	capManager := capture.NewManager()
+	m := NewManager(capManager)
+
+	cfg := config.CameraConfig{ID: "cam1", Name: "cam1", Type: "push"}
+	if _, err := capManager.AddCapturer(cfg); err != nil {
+		t.Fatal(err)
+	}
+	m.Snapshot("cam1", time.Second)
+	m.emit(Event{CameraID: "cam1", Detector: "loud", Type: config.AudioDetectThreshold, Time: time.Now()})
+	if len(m.Events("cam1", 0)) != 1 {
+		t.Fatal("事件未记录")
+	}
+
+	if err := capManager.RemoveCapturer("cam1"); err != nil {
+		t.Fatal(err)
+	}
+
+	m.mutex.Lock()
+	_, hasMeter := m.meters["cam1"]
+	_, hasEvents := m.events["cam1"]
+	m.mutex.Unlock()
+	if hasMeter || hasEvents {
+		t.Fatalf("移除后仍保留状态: meter=%v events=%v", hasMeter, hasEvents)
+	}
+	if got := m.Events("cam1", 0); len(got) != 0 {
+		t.Fatalf("移除后仍返回旧事件: %v", got)
+	}
+}
@@ -0,0 +1,153 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"home-monitor/internal/config"
+)
+
+// 每个音频块的时长（采集器输出 48kHz 单声道，每块 960 个采样）
+const chunkDuration = 20 * time.Millisecond
+
+// Event 音频检测器触发的事件
+type Event struct {
+	CameraID string    `json:"camera_id"`
+	Detector string    `json:"detector"`      // 检测器名称
+	Type     string    `json:"type"`          // threshold, sustained, silence
+	Time     time.Time `json:"time"`          // 触发时间（满足最短时长的时刻）
+	Start    time.Time `json:"start"`         // 条件开始成立的时间（声音出现或恢复安静）
+	Level    float64   `json:"level_dbfs"`    // 触发时的 RMS 电平
+	Baseline float64   `json:"baseline_dbfs"` // 环境基线（仅 sustained）
+	Message  string    `json:"message"`
+}
+
+// detector 单个检测器的运行状态
+type detector struct {
+	cfg         config.AudioDetector
+	minDuration time.Duration
+	cooldown    time.Duration
+
+	since     time.Time // 条件开始成立的时间，零值表示不成立
+	fired     bool      // 本次条件成立期间是否已触发
+	lastEvent time.Time
+
+	// sustained：基线（dBFS 指数平均）及已累计的时长
+	baseline   float64
+	baselineAt time.Duration
+
+	// silence：此前是否出现过声音
+	noisy bool
+}
+
+// newDetector 创建检测器
+func newDetector(cfg config.AudioDetector) *detector {
+	return &detector{
+		cfg:         cfg,
+		minDuration: time.Duration(cfg.MinDurationMs) * time.Millisecond,
+		cooldown:    time.Duration(cfg.Cooldown) * time.Second,
+		baseline:    MinDBFS,
+	}
+}
+
+// reset 清除运行状态（隐私模式等期间音频不是真实声音）
+func (d *detector) reset() {
+	d.since = time.Time{}
+	d.fired = false
+	d.noisy = false
+}
+
+// feed 输入一个音频块的电平，满足触发条件时返回事件
+func (d *detector) feed(level Level) *Event {
+	switch d.cfg.Type {
+	case config.AudioDetectThreshold:
+		return d.hold(level, level.RMS >= d.cfg.ThresholdDBFS)
+	case config.AudioDetectSustained:
+		return d.feedSustained(level)
+	case config.AudioDetectSilence:
+		return d.feedSilence(level)
+	}
+	return nil
+}
+
+// hold 条件持续成立 minDuration 后触发一次，条件不成立后重新计时
+func (d *detector) hold(level Level, active bool) *Event {
+	if !active {
+		d.since = time.Time{}
+		d.fired = false
+		return nil
+	}
+	if d.since.IsZero() {
+		d.since = level.Time
+	}
+	if d.fired || level.Time.Sub(d.since)+chunkDuration < d.minDuration {
+		return nil
+	}
+	// 冷却期内不触发，条件仍成立则冷却结束后触发
+	if !d.lastEvent.IsZero() && level.Time.Sub(d.lastEvent) < d.cooldown {
+		return nil
+	}
+	d.fired = true
+	d.lastEvent = level.Time
+	return d.event(level)
+}
+
+// feedSustained 电平持续高于基线 margin_db；基线只在未触发时更新，建立前不触发
+func (d *detector) feedSustained(level Level) *Event {
+	window := time.Duration(d.cfg.BaselineSeconds) * time.Second
+	ready := d.baselineAt >= window
+	active := ready && level.RMS >= d.baseline+d.cfg.MarginDB
+
+	// 触发后继续更新基线，环境噪声长期变大时逐渐适应，不会反复触发
+	if !active || d.fired {
+		if d.baselineAt == 0 {
+			d.baseline = level.RMS
+		} else {
+			alpha := float64(chunkDuration) / float64(window)
+			d.baseline += alpha * (level.RMS - d.baseline)
+		}
+		d.baselineAt = min(d.baselineAt+chunkDuration, window)
+	}
+	return d.hold(level, active)
+}
+
+// feedSilence 电平超过阈值后回落，并保持安静 minDuration 时触发
+func (d *detector) feedSilence(level Level) *Event {
+	if level.RMS >= d.cfg.ThresholdDBFS {
+		d.noisy = true
+		d.since = time.Time{}
+		d.fired = false
+		return nil
+	}
+	if !d.noisy {
+		return nil
+	}
+	event := d.hold(level, true)
+	if d.fired {
+		d.noisy = false
+	}
+	return event
+}
+
+// event 生成事件
+func (d *detector) event(level Level) *Event {
+	event := &Event{
+		Detector: d.cfg.Name,
+		Type:     d.cfg.Type,
+		Time:     level.Time,
+		Start:    d.since,
+		Level:    level.RMS,
+	}
+	duration := level.Time.Sub(d.since) + chunkDuration
+	switch d.cfg.Type {
+	case config.AudioDetectThreshold:
+		event.Message = fmt.Sprintf("声音超过 %g dBFS 持续 %v（%s）", d.cfg.ThresholdDBFS, duration.Round(10*time.Millisecond), d.cfg.Name)
+	case config.AudioDetectSustained:
+		event.Baseline = math.Round(d.baseline*10) / 10
+		event.Message = fmt.Sprintf("持续声音高于环境 %.1f dB 已 %v（%s）", level.RMS-d.baseline, duration.Round(time.Second), d.cfg.Name)
+	case config.AudioDetectSilence:
+		event.Message = fmt.Sprintf("声音停止后安静 %v（%s）", duration.Round(time.Second), d.cfg.Name)
+	}
+	return event
+}
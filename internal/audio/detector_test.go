@@ -0,0 +1,140 @@
+package audio
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"home-monitor/internal/config"
+)
+
+// segment 一段恒定电平的音频
+type segment struct {
+	rms      float64
+	duration time.Duration
+}
+
+// epoch 合成电平序列的起始时间
+var epoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// runDetector 按 20ms 一块输入合成电平，返回各事件相对起点的触发时间
+func runDetector(cfg config.AudioDetector, segments []segment) []time.Duration {
+	d := newDetector(cfg)
+	var fired []time.Duration
+	now := epoch
+	for _, seg := range segments {
+		for elapsed := time.Duration(0); elapsed < seg.duration; elapsed += chunkDuration {
+			if event := d.feed(Level{Time: now, RMS: seg.rms, Peak: seg.rms}); event != nil {
+				fired = append(fired, now.Sub(epoch))
+			}
+			now = now.Add(chunkDuration)
+		}
+	}
+	return fired
+}
+
+// ms 毫秒
+func ms(n int) time.Duration {
+	return time.Duration(n) * time.Millisecond
+}
+
+func TestDetectorFeed(t *testing.T) {
+	threshold := config.AudioDetector{Name: "loud", Type: config.AudioDetectThreshold, ThresholdDBFS: -20, MinDurationMs: 100, Cooldown: 10}
+	sustained := config.AudioDetector{Name: "crying", Type: config.AudioDetectSustained, MarginDB: 10, BaselineSeconds: 5, MinDurationMs: 1000, Cooldown: 10}
+	silence := config.AudioDetector{Name: "quiet", Type: config.AudioDetectSilence, ThresholdDBFS: -30, MinDurationMs: 2000, Cooldown: 0}
+
+	tests := []struct {
+		name     string
+		cfg      config.AudioDetector
+		segments []segment
+		want     []time.Duration
+	}{
+		// 最短时长边界：100ms 为 5 个音频块，第 5 块（起点 80ms）时满足
+		{"不足最短时长", threshold, []segment{{-10, ms(80)}, {-50, ms(1000)}}, nil},
+		{"恰好最短时长", threshold, []segment{{-10, ms(100)}, {-50, ms(1000)}}, []time.Duration{ms(80)}},
+		{"等于阈值即成立", threshold, []segment{{-20, ms(100)}}, []time.Duration{ms(80)}},
+		{"低于阈值不触发", threshold, []segment{{-20.1, ms(5000)}}, nil},
+		{"持续期间只触发一次", threshold, []segment{{-10, ms(5000)}}, []time.Duration{ms(80)}},
+
+		// 冷却期
+		{"冷却期内的短促声音不触发", threshold,
+			[]segment{{-10, ms(100)}, {-50, ms(2000)}, {-10, ms(100)}, {-50, ms(2000)}},
+			[]time.Duration{ms(80)}},
+		{"冷却期内开始仍持续的声音在冷却结束时触发", threshold,
+			[]segment{{-10, ms(100)}, {-50, ms(2000)}, {-10, ms(12000)}},
+			[]time.Duration{ms(80), ms(10080)}},
+		{"冷却结束后重新触发", threshold,
+			[]segment{{-10, ms(100)}, {-50, ms(10000)}, {-10, ms(100)}},
+			[]time.Duration{ms(80), ms(10180)}},
+
+		// 基线建立前不触发
+		{"基线建立前的声音不触发", sustained, []segment{{-10, ms(4000)}}, nil},
+		{"从一开始就持续的声音成为基线", sustained, []segment{{-10, ms(20000)}}, nil},
+		{"基线建立后触发", sustained,
+			[]segment{{-40, ms(5000)}, {-20, ms(2000)}},
+			[]time.Duration{ms(5980)}},
+		{"高于基线不足余量不触发", sustained,
+			[]segment{{-40, ms(5000)}, {-31, ms(5000)}}, nil},
+
+		// 安静：只在出现声音后触发一次
+		{"没有声音时不触发", silence, []segment{{-60, ms(10000)}}, nil},
+		{"声音后安静触发一次", silence,
+			[]segment{{-60, ms(1000)}, {-10, ms(500)}, {-60, ms(10000)}},
+			[]time.Duration{ms(3480)}},
+		{"安静不足时长时再次出现声音重新计时", silence,
+			[]segment{{-10, ms(500)}, {-60, ms(1000)}, {-10, ms(500)}, {-60, ms(2000)}},
+			[]time.Duration{ms(3980)}},
+		{"每次声音后各触发一次", silence,
+			[]segment{{-10, ms(500)}, {-60, ms(3000)}, {-10, ms(500)}, {-60, ms(3000)}},
+			[]time.Duration{ms(2480), ms(5980)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runDetector(tt.cfg, tt.segments); !slices.Equal(got, tt.want) {
+				t.Errorf("触发时间 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectorReset(t *testing.T) {
+	d := newDetector(config.AudioDetector{Name: "quiet", Type: config.AudioDetectSilence, ThresholdDBFS: -30, MinDurationMs: 100})
+	now := epoch
+	feed := func(rms float64, n int) *Event {
+		var last *Event
+		for i := 0; i < n; i++ {
+			if event := d.feed(Level{Time: now, RMS: rms}); event != nil {
+				last = event
+			}
+			now = now.Add(chunkDuration)
+		}
+		return last
+	}
+
+	feed(-10, 5)
+	d.reset() // 如进入隐私模式
+	if event := feed(-96, 50); event != nil {
+		t.Fatalf("重置后不应因之前的声音触发: %+v", event)
+	}
+}
+
+func TestDetectorEvent(t *testing.T) {
+	d := newDetector(config.AudioDetector{Name: "loud", Type: config.AudioDetectThreshold, ThresholdDBFS: -20, MinDurationMs: 40})
+	if event := d.feed(Level{Time: epoch, RMS: -12.3}); event != nil {
+		t.Fatal("第一块不应触发")
+	}
+	event := d.feed(Level{Time: epoch.Add(chunkDuration), RMS: -8})
+	if event == nil {
+		t.Fatal("未触发")
+	}
+	if event.Detector != "loud" || event.Type != config.AudioDetectThreshold {
+		t.Errorf("检测器 = %s/%s", event.Detector, event.Type)
+	}
+	if !event.Start.Equal(epoch) || !event.Time.Equal(epoch.Add(chunkDuration)) {
+		t.Errorf("start = %v, time = %v", event.Start, event.Time)
+	}
+	if event.Level != -8 {
+		t.Errorf("level = %v, want -8", event.Level)
+	}
+}
@@ -0,0 +1,151 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"sync"
+	"time"
+
+	"home-monitor/internal/capture"
+)
+
+const (
+	// MinDBFS 电平下限（16 位 PCM 的动态范围），静音记为该值
+	MinDBFS = -96.0
+	// 每个摄像头保留的电平历史时长（按 20ms 音频块约 500 个点）
+	historyDuration = 10 * time.Second
+	historySize     = 500
+	// 实时订阅者的缓冲点数，跟不上时丢弃
+	subscriberBuffer = 100
+)
+
+// Level 一个音频块的电平
+type Level struct {
+	Seq  uint64    `json:"seq"`       // 音频块序号
+	Time time.Time `json:"time"`      // 采集时间
+	RMS  float64   `json:"rms_dbfs"`  // 均方根电平（dBFS）
+	Peak float64   `json:"peak_dbfs"` // 峰值电平（dBFS）
+}
+
+// measure 计算 S16LE PCM 数据的 RMS 和峰值电平
+func measure(chunk *capture.AudioChunk) Level {
+	samples := len(chunk.Data) / 2
+	var sum float64
+	var peak int
+	for i := 0; i < samples; i++ {
+		s := int(int16(binary.LittleEndian.Uint16(chunk.Data[i*2:])))
+		sum += float64(s * s)
+		if s < 0 {
+			s = -s
+		}
+		peak = max(peak, s)
+	}
+
+	level := Level{Seq: chunk.Seq, Time: chunk.CapturedAt, RMS: MinDBFS, Peak: MinDBFS}
+	if samples > 0 {
+		level.RMS = toDBFS(math.Sqrt(sum/float64(samples)) / 32768)
+		level.Peak = toDBFS(float64(peak) / 32768)
+	}
+	return level
+}
+
+// toDBFS 将 0-1 的幅度换算为 dBFS，保留一位小数，不低于 MinDBFS
+func toDBFS(amplitude float64) float64 {
+	if amplitude <= 0 {
+		return MinDBFS
+	}
+	db := math.Round(math.Max(20*math.Log10(amplitude), MinDBFS)*10) / 10
+	if db == 0 {
+		// 避免满幅时输出 -0
+		return 0
+	}
+	return db
+}
+
+// Combine 合并多个电平：RMS 按能量平均，峰值取最大，时间和序号取最后一个
+func Combine(levels []Level) Level {
+	if len(levels) == 0 {
+		return Level{RMS: MinDBFS, Peak: MinDBFS}
+	}
+	combined := levels[len(levels)-1]
+	var power float64
+	for _, l := range levels {
+		power += math.Pow(10, l.RMS/10)
+		combined.Peak = math.Max(combined.Peak, l.Peak)
+	}
+	combined.RMS = toDBFS(math.Sqrt(power / float64(len(levels))))
+	return combined
+}
+
+// meter 单个摄像头的电平计量：环形历史和实时订阅者
+type meter struct {
+	history []Level
+	next    int
+
+	subscribers map[chan Level]struct{}
+	mutex       sync.Mutex
+}
+
+// newMeter 创建电平计量
+func newMeter() *meter {
+	return &meter{
+		history:     make([]Level, 0, historySize),
+		subscribers: make(map[chan Level]struct{}),
+	}
+}
+
+// process 计量一个音频块，记录历史并推送给实时订阅者
+func (m *meter) process(chunk *capture.AudioChunk) Level {
+	level := measure(chunk)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if len(m.history) < historySize {
+		m.history = append(m.history, level)
+	} else {
+		m.history[m.next] = level
+		m.next = (m.next + 1) % historySize
+	}
+
+	for ch := range m.subscribers {
+		select {
+		case ch <- level:
+		default:
+		}
+	}
+	return level
+}
+
+// snapshot 按时间顺序返回最近 since 内的电平历史
+func (m *meter) snapshot(since time.Duration) []Level {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	ordered := make([]Level, 0, len(m.history))
+	ordered = append(ordered, m.history[m.next:]...)
+	ordered = append(ordered, m.history[:m.next]...)
+
+	cutoff := time.Now().Add(-since)
+	for i, level := range ordered {
+		if !level.Time.Before(cutoff) {
+			return ordered[i:]
+		}
+	}
+	return []Level{}
+}
+
+// subscribe 实时订阅电平，返回的函数用于取消订阅
+func (m *meter) subscribe() (<-chan Level, func()) {
+	ch := make(chan Level, subscriberBuffer)
+
+	m.mutex.Lock()
+	m.subscribers[ch] = struct{}{}
+	m.mutex.Unlock()
+
+	return ch, func() {
+		m.mutex.Lock()
+		delete(m.subscribers, ch)
+		m.mutex.Unlock()
+	}
+}
@@ -0,0 +1,482 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"home-monitor/internal/config"
+)
+
+// baseCapturer 采集器公共部分：生命周期、管线监督、帧/音频分发
+// 具体采集器嵌入该结构并提供 runFunc（运行一次采集管线）
+type baseCapturer struct {
+	config config.CameraConfig
+
+	// 管线监督器，运行 run 或隐私模式的占位画面
+	supervisor *supervisor
+	run        runFunc
+
+	// 隐私模式：手动设置与当前状态
+	privacyMode   PrivacyMode
+	privacyActive bool
+	privacySince  time.Time
+	privacyMu     sync.Mutex
+
+	// 音频订阅者：带元数据的音频块订阅与兼容旧接口的 []byte 订阅
+	audioChunkSubscribers map[string]*subscriber[*AudioChunk]
+	audioSubscribers      map[string]*subscriber[[]byte]
+	audioMutex            sync.RWMutex
+
+	// 音频块序号，跨管线重启连续
+	audioSeq atomic.Uint64
+
+	running bool
+	mutex   sync.RWMutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// 预览档位：帧序号与最新帧缓存，main 始终存在
+	profiles    map[string]*profileState
+	profileList []string // 按添加顺序，main 在最前
+	lastFrameMu sync.RWMutex
+
+	// 帧订阅者：引用计数帧订阅与兼容旧接口的 []byte 订阅
+	frameRefSubscribers map[string]*subscriber[*Frame]
+	frameSubscribers    map[string]*subscriber[[]byte]
+	frameMutex          sync.RWMutex
+
+	done chan struct{}
+}
+
+// MainProfile 主预览档位名称，未指定档位的订阅和快照使用该档位
+const MainProfile = config.MainProfile
+
+// profileState 单个预览档位的状态
+type profileState struct {
+	seq       atomic.Uint64 // 帧序号，跨管线重启连续
+	lastFrame *Frame        // 最新帧缓存（持有一个引用），由 lastFrameMu 保护
+
+	// 配置的输出尺寸（额外档位），用于生成占位画面
+	width, height int
+}
+
+// init 初始化公共字段，privacyMode 为创建时的隐私模式手动设置
+func (c *baseCapturer) init(cfg config.CameraConfig, privacyMode PrivacyMode, run runFunc) {
+	c.config = cfg
+	c.run = run
+	c.privacyMode = privacyMode
+	c.frameRefSubscribers = make(map[string]*subscriber[*Frame])
+	c.frameSubscribers = make(map[string]*subscriber[[]byte])
+	c.audioChunkSubscribers = make(map[string]*subscriber[*AudioChunk])
+	c.audioSubscribers = make(map[string]*subscriber[[]byte])
+	c.profiles = map[string]*profileState{MainProfile: {}}
+	c.profileList = []string{MainProfile}
+	c.done = make(chan struct{})
+	c.supervisor = newSupervisor(cfg.ID, time.Duration(cfg.StallTimeout)*time.Second, c.runOrPrivacy)
+}
+
+// GetID 获取采集器ID
+func (c *baseCapturer) GetID() string {
+	return c.config.ID
+}
+
+// GetName 获取采集器名称
+func (c *baseCapturer) GetName() string {
+	return c.config.Name
+}
+
+// GetConfig 获取配置
+func (c *baseCapturer) GetConfig() config.CameraConfig {
+	return c.config
+}
+
+// IsRunning 检查是否运行中
+func (c *baseCapturer) IsRunning() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.running
+}
+
+// HasAudio 是否启用了音频
+func (c *baseCapturer) HasAudio() bool {
+	return c.config.Audio.Enabled
+}
+
+// VideoSize 主预览画面尺寸，裁剪、旋转后的宽高；HTTP、推送型来源未配置尺寸时为 0
+func (c *baseCapturer) VideoSize() (int, int) {
+	return c.config.Transform.OutputSize(c.config.Width, c.config.Height)
+}
+
+// addProfile 注册一个额外的预览档位，仅在启动前调用
+func (c *baseCapturer) addProfile(name string, width, height int) {
+	if _, exists := c.profiles[name]; exists {
+		return
+	}
+	c.profiles[name] = &profileState{width: width, height: height}
+	c.profileList = append(c.profileList, name)
+}
+
+// Profiles 获取可用的预览档位名称，main 在最前
+func (c *baseCapturer) Profiles() []string {
+	return append([]string(nil), c.profileList...)
+}
+
+// HasProfile 是否存在指定预览档位
+func (c *baseCapturer) HasProfile(name string) bool {
+	_, exists := c.profiles[name]
+	return exists
+}
+
+// GetStatus 获取采集管线状态（状态、重启次数、最近错误、隐私模式）
+func (c *baseCapturer) GetStatus() Status {
+	status := c.supervisor.getStatus()
+	status.Privacy = c.PrivacyStatus()
+	return status
+}
+
+// Start 启动采集器
+func (c *baseCapturer) Start(ctx context.Context) error {
+	c.mutex.Lock()
+	if c.running {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.mutex.Unlock()
+
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+	c.supervisor.setState(StateStarting)
+
+	// 启动前确定隐私模式，配置了计划时段时定期检查
+	c.updatePrivacy()
+	if len(c.config.PrivacySchedules) > 0 {
+		go c.watchPrivacySchedule(c.ctx)
+	}
+
+	// 启动监督循环（负责启动 FFmpeg 并在退出后重启）
+	go func() {
+		defer close(c.done)
+		c.supervisor.loop(c.ctx)
+	}()
+
+	c.mutex.Lock()
+	c.running = true
+	c.mutex.Unlock()
+
+	log.Printf("音视频采集器 %s (%s) 已启动", c.config.Name, c.config.ID)
+	return nil
+}
+
+// Stop 停止采集器
+func (c *baseCapturer) Stop() error {
+	c.mutex.Lock()
+	if !c.running {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.mutex.Unlock()
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+
+	// 等待监督循环退出（包含 FFmpeg 优雅退出时间）
+	select {
+	case <-c.done:
+	case <-time.After(gracefulStopTimeout + 5*time.Second):
+	}
+
+	// 关闭所有订阅者通道（移出订阅表后在锁外关闭）
+	c.frameMutex.Lock()
+	frameRefSubs := selectSubscribers(c.frameRefSubscribers, "")
+	frameSubs := selectSubscribers(c.frameSubscribers, "")
+	clear(c.frameRefSubscribers)
+	clear(c.frameSubscribers)
+	c.frameMutex.Unlock()
+	closeSubscribers(frameRefSubs)
+	closeSubscribers(frameSubs)
+
+	c.audioMutex.Lock()
+	audioChunkSubs := selectSubscribers(c.audioChunkSubscribers, "")
+	audioSubs := selectSubscribers(c.audioSubscribers, "")
+	clear(c.audioChunkSubscribers)
+	clear(c.audioSubscribers)
+	c.audioMutex.Unlock()
+	closeSubscribers(audioChunkSubs)
+	closeSubscribers(audioSubs)
+
+	c.lastFrameMu.Lock()
+	for _, state := range c.profiles {
+		if state.lastFrame != nil {
+			state.lastFrame.Release()
+			state.lastFrame = nil
+		}
+	}
+	c.lastFrameMu.Unlock()
+
+	c.mutex.Lock()
+	c.running = false
+	c.mutex.Unlock()
+
+	log.Printf("音视频采集器 %s (%s) 已停止", c.config.Name, c.config.ID)
+	return nil
+}
+
+// broadcastFrame 广播主档位帧，见 broadcastProfileFrame
+func (c *baseCapturer) broadcastFrame(frame *Frame) {
+	c.broadcastProfileFrame(MainProfile, frame)
+}
+
+// broadcastProfileFrame 填写帧元数据，缓存该档位最新帧并广播给订阅了该档位的订阅者
+// 所有订阅者共享同一缓冲区，不复制；调用方仍持有自己的引用，广播后自行 Release
+func (c *baseCapturer) broadcastProfileFrame(profile string, frame *Frame) {
+	state, exists := c.profiles[profile]
+	if !exists {
+		return
+	}
+
+	// 喂看门狗（以主档位为准）
+	if profile == MainProfile {
+		c.supervisor.noteFrame()
+	}
+
+	now := time.Now()
+	width, height := jpegDimensions(frame.data)
+	frame.meta = FrameMeta{
+		CameraID:   c.config.ID,
+		Profile:    profile,
+		Seq:        state.seq.Add(1),
+		CapturedAt: now,
+		Mono:       now.Sub(monoEpoch),
+		Width:      width,
+		Height:     height,
+	}
+
+	c.lastFrameMu.Lock()
+	old := state.lastFrame
+	state.lastFrame = frame.Retain()
+	c.lastFrameMu.Unlock()
+	if old != nil {
+		old.Release()
+	}
+
+	// 在锁外投递，阻塞型订阅者等待时不影响订阅变更和其他档位
+	c.frameMutex.RLock()
+	refSubs := selectSubscribers(c.frameRefSubscribers, profile)
+	byteSubs := selectSubscribers(c.frameSubscribers, profile)
+	c.frameMutex.RUnlock()
+
+	fanOut(refSubs, frame.Retain)
+
+	if len(byteSubs) > 0 {
+		data := frame.detach()
+		fanOut(byteSubs, func() []byte { return data })
+	}
+}
+
+// GetFrameRef 获取主档位当前帧的引用，使用完毕后需调用 Release
+func (c *baseCapturer) GetFrameRef() (*Frame, error) {
+	return c.GetProfileFrameRef(MainProfile)
+}
+
+// GetProfileFrameRef 获取指定档位当前帧的引用，使用完毕后需调用 Release
+func (c *baseCapturer) GetProfileFrameRef(profile string) (*Frame, error) {
+	state, exists := c.profiles[profile]
+	if !exists {
+		return nil, fmt.Errorf("预览档位不存在: %s", profile)
+	}
+
+	c.mutex.RLock()
+	running := c.running
+	c.mutex.RUnlock()
+
+	if !running {
+		return nil, fmt.Errorf("采集器未运行")
+	}
+
+	// 画面停滞时不返回过期的缓存帧
+	if status := c.supervisor.getStatus(); status.Stalled {
+		return nil, fmt.Errorf("画面停滞，最近一帧在 %.0f 秒前", status.LastFrameAge)
+	}
+
+	c.lastFrameMu.RLock()
+	frame := state.lastFrame
+	if frame != nil {
+		frame.Retain()
+	}
+	c.lastFrameMu.RUnlock()
+
+	if frame != nil {
+		return frame, nil
+	}
+
+	subID := fmt.Sprintf("snapshot_%d", time.Now().UnixNano())
+	ch := c.SubscribeFrameRefs(subID, WithPolicy(LatestOnly), WithProfile(profile), WithLabel("snapshot"))
+	defer c.UnsubscribeFrameRefs(subID)
+
+	select {
+	case frame, ok := <-ch:
+		if !ok {
+			return nil, fmt.Errorf("采集器已停止")
+		}
+		return frame, nil
+	case <-time.After(3 * time.Second):
+		return nil, fmt.Errorf("获取帧超时")
+	}
+}
+
+// GetFrame 获取当前帧（只读，调用方不得修改）
+func (c *baseCapturer) GetFrame() ([]byte, error) {
+	frame, err := c.GetFrameRef()
+	if err != nil {
+		return nil, err
+	}
+	data := frame.detach()
+	frame.Release()
+	return data, nil
+}
+
+// 默认订阅参数：视频保留最新帧，音频保证连续性
+var (
+	defaultFrameOptions = subscribeOptions{policy: DropOldest, bufferSize: 30, profile: MainProfile}
+	defaultAudioOptions = subscribeOptions{policy: DropNewest, bufferSize: 100} // 100 个 20ms 块 = 2秒
+)
+
+// SubscribeFrameRefs 订阅引用计数帧，每收到一帧使用完毕后需调用 Release
+func (c *baseCapturer) SubscribeFrameRefs(id string, opts ...SubscribeOption) <-chan *Frame {
+	c.frameMutex.Lock()
+	defer c.frameMutex.Unlock()
+
+	sub := newSubscriber(id, "video", defaultFrameOptions, opts, (*Frame).Release)
+	c.frameRefSubscribers[id] = sub
+	return sub.ch
+}
+
+// UnsubscribeFrameRefs 取消引用计数帧订阅
+func (c *baseCapturer) UnsubscribeFrameRefs(id string) {
+	c.frameMutex.Lock()
+	sub, exists := c.frameRefSubscribers[id]
+	delete(c.frameRefSubscribers, id)
+	c.frameMutex.Unlock()
+
+	if exists {
+		sub.close()
+	}
+}
+
+// SubscribeFrames 订阅帧数据（兼容接口）
+// 收到的切片与其他订阅者共享，只读；缓冲区不再回收到池中，新代码应使用 SubscribeFrameRefs
+func (c *baseCapturer) SubscribeFrames(id string, opts ...SubscribeOption) <-chan []byte {
+	c.frameMutex.Lock()
+	defer c.frameMutex.Unlock()
+
+	sub := newSubscriber[[]byte](id, "video", defaultFrameOptions, opts, nil)
+	c.frameSubscribers[id] = sub
+	return sub.ch
+}
+
+// UnsubscribeFrames 取消订阅帧数据
+func (c *baseCapturer) UnsubscribeFrames(id string) {
+	c.frameMutex.Lock()
+	sub, exists := c.frameSubscribers[id]
+	delete(c.frameSubscribers, id)
+	c.frameMutex.Unlock()
+
+	if exists {
+		sub.close()
+	}
+}
+
+// broadcastAudio 广播 PCM 音频数据给订阅者，数据复制一次后由所有订阅者共享
+func (c *baseCapturer) broadcastAudio(audio []byte) {
+	c.audioMutex.RLock()
+	chunkSubs := selectSubscribers(c.audioChunkSubscribers, "")
+	byteSubs := selectSubscribers(c.audioSubscribers, "")
+	c.audioMutex.RUnlock()
+
+	if len(chunkSubs) == 0 && len(byteSubs) == 0 {
+		return
+	}
+
+	now := time.Now()
+	chunk := &AudioChunk{
+		CameraID:   c.config.ID,
+		Seq:        c.audioSeq.Add(1),
+		CapturedAt: now,
+		Mono:       now.Sub(monoEpoch),
+		SampleRate: audioSampleRate,
+		Channels:   audioChannels,
+		Data:       append([]byte(nil), audio...),
+	}
+
+	fanOut(chunkSubs, func() *AudioChunk { return chunk })
+	fanOut(byteSubs, func() []byte { return chunk.Data })
+}
+
+// SubscribeAudioChunks 订阅带元数据的音频块（只读）
+func (c *baseCapturer) SubscribeAudioChunks(id string, opts ...SubscribeOption) <-chan *AudioChunk {
+	c.audioMutex.Lock()
+	defer c.audioMutex.Unlock()
+
+	sub := newSubscriber[*AudioChunk](id, "audio", defaultAudioOptions, opts, nil)
+	c.audioChunkSubscribers[id] = sub
+	return sub.ch
+}
+
+// UnsubscribeAudioChunks 取消订阅音频块
+func (c *baseCapturer) UnsubscribeAudioChunks(id string) {
+	c.audioMutex.Lock()
+	sub, exists := c.audioChunkSubscribers[id]
+	delete(c.audioChunkSubscribers, id)
+	c.audioMutex.Unlock()
+
+	if exists {
+		sub.close()
+	}
+}
+
+// SubscribeAudio 订阅音频数据（兼容接口）
+// 收到的切片与其他订阅者共享，只读；新代码应使用 SubscribeAudioChunks
+func (c *baseCapturer) SubscribeAudio(id string, opts ...SubscribeOption) <-chan []byte {
+	c.audioMutex.Lock()
+	defer c.audioMutex.Unlock()
+
+	sub := newSubscriber[[]byte](id, "audio", defaultAudioOptions, opts, nil)
+	c.audioSubscribers[id] = sub
+	return sub.ch
+}
+
+// UnsubscribeAudio 取消订阅音频数据
+func (c *baseCapturer) UnsubscribeAudio(id string) {
+	c.audioMutex.Lock()
+	sub, exists := c.audioSubscribers[id]
+	delete(c.audioSubscribers, id)
+	c.audioMutex.Unlock()
+
+	if exists {
+		sub.close()
+	}
+}
+
+// SubscriberStats 获取所有帧/音频订阅者的投递统计
+func (c *baseCapturer) SubscriberStats() []SubscriberStats {
+	stats := []SubscriberStats{}
+
+	c.frameMutex.RLock()
+	stats = collectStats(stats, c.frameRefSubscribers)
+	stats = collectStats(stats, c.frameSubscribers)
+	c.frameMutex.RUnlock()
+
+	c.audioMutex.RLock()
+	stats = collectStats(stats, c.audioChunkSubscribers)
+	stats = collectStats(stats, c.audioSubscribers)
+	c.audioMutex.RUnlock()
+
+	sortStats(stats)
+	return stats
+}
@@ -1,7 +1,6 @@
 package capture
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
@@ -14,6 +13,7 @@ import (
 	"time"
 
 	"home-monitor/internal/config"
+	"home-monitor/internal/ffmpeglog"
 )
 
 // AVCapturer 统一音视频采集器接口
@@ -25,11 +25,36 @@ type AVCapturer interface {
 	GetConfig() config.CameraConfig
 	IsRunning() bool
 	HasAudio() bool
+	GetStatus() Status
+
+	// 带元数据的帧与音频块（帧使用完毕后需 Release）
+	GetFrameRef() (*Frame, error)
+	SubscribeFrameRefs(id string, opts ...SubscribeOption) <-chan *Frame
+	UnsubscribeFrameRefs(id string)
+	SubscribeAudioChunks(id string, opts ...SubscribeOption) <-chan *AudioChunk
+	UnsubscribeAudioChunks(id string)
+
+	// 兼容接口：只有数据、没有元数据的只读切片
 	GetFrame() ([]byte, error)
-	SubscribeFrames(id string) <-chan []byte
+	SubscribeFrames(id string, opts ...SubscribeOption) <-chan []byte
 	UnsubscribeFrames(id string)
-	SubscribeAudio(id string) <-chan []byte
+	SubscribeAudio(id string, opts ...SubscribeOption) <-chan []byte
 	UnsubscribeAudio(id string)
+
+	// 主预览画面尺寸（已按画面变换换算），未知时为 0
+	VideoSize() (width, height int)
+
+	// 预览档位：main 始终存在，FFmpeg 采集器可配置额外档位
+	Profiles() []string
+	HasProfile(name string) bool
+	GetProfileFrameRef(profile string) (*Frame, error)
+
+	// 订阅者投递统计
+	SubscriberStats() []SubscriberStats
+
+	// 隐私模式：手动设置（auto 按计划时段）与当前状态
+	SetPrivacyMode(mode PrivacyMode)
+	PrivacyStatus() PrivacyStatus
 }
 
 // RecordingConfig 录制配置
@@ -39,262 +64,445 @@ type RecordingConfig struct {
 	Format          string
 }
 
+// 进程优雅退出等待时间（让录像分段正常收尾）
+const gracefulStopTimeout = 3 * time.Second
+
+// 音频管道输出格式（PCM S16LE）
+const (
+	audioSampleRate = 48000
+	audioChannels   = 1
+)
+
 // FFmpegCapturer 基于 FFmpeg 的统一音视频采集器
 // 使用单一 FFmpeg 进程同时输出：
 // 1. MJPEG 帧流（用于 Web 预览）
 // 2. 分段视频文件（用于录像存储）
+// 进程由 supervisor 监督，异常退出后自动退避重启
 type FFmpegCapturer struct {
-	config config.CameraConfig
+	baseCapturer
 
 	// 主采集进程
 	cmd      *exec.Cmd
 	cmdMutex sync.Mutex
 
-	// MJPEG 预览管道
-	mjpegPipe io.ReadCloser
+	// 录制配置与转码档位
+	recordingConfig *RecordingConfig
+	recordEncoder   config.EncoderProfile
 
-	// 音频管道 (PCM S16LE 48kHz mono)
-	audioPipe io.ReadCloser
+	// 当前录像编码方式；探测结果按源地址缓存，重启和切换地址时不再重复探测
+	recording      *RecordingStatus
+	recordingModes map[string]RecordingStatus
+	recordingMu    sync.RWMutex
 
-	// 音频订阅者
-	audioSubscribers map[string]chan []byte
-	audioMutex       sync.RWMutex
+	// 当前使用的 RTSP 地址序号（0 为主地址，之后为备用地址）
+	sourceIndex int
+	sourceMu    sync.RWMutex
 
-	running bool
-	mutex   sync.RWMutex
+	// 画面叠加文字（未启用时为 nil）
+	osd *osdOverlay
 
-	ctx    context.Context
-	cancel context.CancelFunc
+	// 预览输出档位，第一个为 main
+	outputs []previewProfile
 
-	// 帧缓存
-	lastFrame   []byte
-	lastFrameMu sync.RWMutex
+	// MJPEG 流解析计数
+	parserCounters parserCounters
+}
 
-	// 帧订阅者
-	frameSubscribers map[string]chan []byte
-	frameMutex       sync.RWMutex
+// previewProfile 一路 MJPEG 预览输出
+type previewProfile struct {
+	name    string
+	width   int
+	height  int
+	fps     int
+	quality int
+}
 
-	// 录制配置
-	recordingConfig *RecordingConfig
+// 主预览档位默认 JPEG 质量（-q:v，1-31，越小越好）
+const defaultPreviewQuality = 5
 
-	done chan struct{}
+// captureOptions Manager 级别的采集参数，创建采集器时传入
+type captureOptions struct {
+	recording      *RecordingConfig       // 为空表示不录像
+	previewQuality int                    // 0 表示使用默认值
+	encoders       config.EncoderProfiles // 录像转码档位按摄像头配置从中选择
+	osdText        string                 // 该摄像头 OSD 的动态文本（按摄像头填入）
+	privacyMode    PrivacyMode            // 该摄像头隐私模式的手动设置（按摄像头填入）
 }
 
 // NewAVCapturer 创建新的音视频采集器
 func NewAVCapturer(cfg config.CameraConfig) AVCapturer {
-	return &FFmpegCapturer{
-		config:           cfg,
-		frameSubscribers: make(map[string]chan []byte),
-		audioSubscribers: make(map[string]chan []byte),
-		done:             make(chan struct{}),
-	}
+	return newCapturer(cfg, captureOptions{privacyMode: PrivacyAuto})
 }
 
-// GetID 获取采集器ID
-func (c *FFmpegCapturer) GetID() string {
-	return c.config.ID
+// newCapturer 根据摄像头类型创建采集器
+func newCapturer(cfg config.CameraConfig, opts captureOptions) AVCapturer {
+	switch cfg.Type {
+	case "mjpeg_http", "snapshot_http":
+		// HTTP 预览源由纯 Go 采集，不启动 FFmpeg
+		if opts.recording != nil {
+			log.Printf("摄像头 %s 为 HTTP 预览源，不进行录像", cfg.ID)
+		}
+		return newHTTPCapturer(cfg, opts)
+	case "push":
+		// 推送源由设备主动上传帧
+		return newPushCapturer(cfg, opts)
+	default:
+		return newFFmpegCapturer(cfg, opts)
+	}
 }
 
-// GetName 获取采集器名称
-func (c *FFmpegCapturer) GetName() string {
-	return c.config.Name
-}
+// newFFmpegCapturer 创建 FFmpeg 采集器
+func newFFmpegCapturer(cfg config.CameraConfig, opts captureOptions) *FFmpegCapturer {
+	quality := opts.previewQuality
+	if quality <= 0 {
+		quality = defaultPreviewQuality
+	}
 
-// GetConfig 获取配置
-func (c *FFmpegCapturer) GetConfig() config.CameraConfig {
-	return c.config
+	// 预览输出尺寸按画面变换（裁剪、旋转）换算
+	width, height := cfg.Transform.OutputSize(cfg.Width, cfg.Height)
+	c := &FFmpegCapturer{
+		recordingConfig: opts.recording,
+		recordEncoder:   opts.encoders.Resolve(cfg.Encoders.Record, config.EncoderRecord),
+		osd:             newOSDOverlay(cfg, opts.osdText),
+		outputs: []previewProfile{{
+			name:    MainProfile,
+			width:   width,
+			height:  height,
+			fps:     cfg.FPS,
+			quality: quality,
+		}},
+	}
+	for _, p := range cfg.Profiles {
+		profile := previewProfile{
+			name:    p.Name,
+			fps:     p.FPS,
+			quality: p.Quality,
+		}
+		profile.width, profile.height = cfg.Transform.OutputSize(p.Width, p.Height)
+		if profile.fps <= 0 {
+			profile.fps = cfg.FPS
+		}
+		if profile.quality <= 0 {
+			profile.quality = quality
+		}
+		c.outputs = append(c.outputs, profile)
+	}
+
+	c.init(cfg, opts.privacyMode, c.runCapture)
+	for _, p := range c.outputs[1:] {
+		c.addProfile(p.name, p.width, p.height)
+	}
+	return c
 }
 
-// IsRunning 检查是否运行中
-func (c *FFmpegCapturer) IsRunning() bool {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-	return c.running
+// GetStatus 获取采集管线状态，附带 MJPEG 流解析计数和录像编码方式
+func (c *FFmpegCapturer) GetStatus() Status {
+	status := c.baseCapturer.GetStatus()
+	stats := c.parserCounters.snapshot()
+	status.Parser = &stats
+
+	c.recordingMu.RLock()
+	if c.recording != nil {
+		recording := *c.recording
+		status.Recording = &recording
+	}
+	c.recordingMu.RUnlock()
+
+	if c.config.Type == "rtsp" {
+		status.Source = config.MaskURL(c.currentSource())
+		status.SubSource = config.MaskURL(c.config.RTSP.SubURL)
+	}
+	return status
 }
 
-// HasAudio 是否启用了音频
-func (c *FFmpegCapturer) HasAudio() bool {
-	return c.config.Audio.Enabled
+// sourceURLs RTSP 主地址和备用地址
+func (c *FFmpegCapturer) sourceURLs() []string {
+	return append([]string{c.config.RTSPUrl}, c.config.RTSP.FallbackURLs...)
 }
 
-// SetRecordingConfig 设置录制配置
-func (c *FFmpegCapturer) SetRecordingConfig(cfg RecordingConfig) {
-	c.recordingConfig = &cfg
+// currentSource 当前使用的 RTSP 地址
+func (c *FFmpegCapturer) currentSource() string {
+	c.sourceMu.RLock()
+	defer c.sourceMu.RUnlock()
+	return c.sourceURLs()[c.sourceIndex]
 }
 
-// Start 启动采集器
-func (c *FFmpegCapturer) Start(ctx context.Context) error {
-	c.mutex.Lock()
-	if c.running {
-		c.mutex.Unlock()
-		return nil
+// failover 管线退出（无法打开或画面停滞）后切换到下一个地址，最后一个之后回到主地址
+// 在备用地址上稳定运行（ranFor 达到 stableRunPeriod）后退出时，下次重启先重试主地址
+func (c *FFmpegCapturer) failover(ranFor time.Duration) {
+	urls := c.sourceURLs()
+	if c.config.Type != "rtsp" || len(urls) < 2 {
+		return
 	}
-	c.mutex.Unlock()
-
-	c.ctx, c.cancel = context.WithCancel(ctx)
-	c.done = make(chan struct{})
 
-	// 启动 FFmpeg 进程
-	if err := c.startCapture(); err != nil {
-		return fmt.Errorf("启动采集失败: %w", err)
+	c.sourceMu.Lock()
+	retryPrimary := c.sourceIndex != 0 && ranFor >= stableRunPeriod
+	if retryPrimary {
+		c.sourceIndex = 0
+	} else {
+		c.sourceIndex = (c.sourceIndex + 1) % len(urls)
 	}
+	index := c.sourceIndex
+	c.sourceMu.Unlock()
 
-	c.mutex.Lock()
-	c.running = true
-	c.mutex.Unlock()
-
-	log.Printf("音视频采集器 %s (%s) 已启动", c.config.Name, c.config.ID)
-	return nil
+	if retryPrimary {
+		log.Printf("摄像头 %s 备用地址曾稳定运行，重试主地址: %s", c.config.ID, config.MaskURL(urls[index]))
+		return
+	}
+	log.Printf("摄像头 %s 切换到 RTSP 地址 %d/%d: %s", c.config.ID, index+1, len(urls), config.MaskURL(urls[index]))
 }
 
-// Stop 停止采集器
-func (c *FFmpegCapturer) Stop() error {
-	c.mutex.Lock()
-	if !c.running {
-		c.mutex.Unlock()
-		return nil
+// updateRecordingMode 启动管线前选择录像编码方式，方式变化时记录日志
+// 每个源地址只在首次使用时探测（采集器重建后重新探测），探测期间暂停停滞检测
+func (c *FFmpegCapturer) updateRecordingMode(ctx context.Context) {
+	if c.recordingConfig == nil {
+		return
 	}
-	c.mutex.Unlock()
 
-	if c.cancel != nil {
-		c.cancel()
+	var source string
+	var input []string
+	if c.config.Type == "rtsp" {
+		source = c.currentSource()
+		input = rtspInputArgs(c.config, source)
 	}
 
-	c.stopCapture()
+	c.recordingMu.RLock()
+	status, cached := c.recordingModes[source]
+	c.recordingMu.RUnlock()
+	if !cached {
+		resume := c.supervisor.pauseWatchdog()
+		status = selectRecordingMode(ctx, c.config, input, c.recordingConfig.Format)
+		resume()
+	}
 
-	// 等待 goroutine 退出
-	select {
-	case <-c.done:
-	case <-time.After(5 * time.Second):
+	c.recordingMu.Lock()
+	// 探测失败或运行被取消时结果不可靠，不缓存
+	if !cached && !status.probeFailed && ctx.Err() == nil {
+		if c.recordingModes == nil {
+			c.recordingModes = make(map[string]RecordingStatus)
+		}
+		c.recordingModes[source] = status
 	}
+	changed := c.recording == nil || c.recording.Mode != status.Mode
+	c.recording = &status
+	c.recordingMu.Unlock()
 
-	// 关闭所有订阅者通道
-	c.frameMutex.Lock()
-	for id, ch := range c.frameSubscribers {
-		close(ch)
-		delete(c.frameSubscribers, id)
+	if changed {
+		log.Printf("摄像头 %s 录像方式: %s（%s）", c.config.ID, status.Mode, status.Reason)
 	}
-	c.frameMutex.Unlock()
+}
 
-	c.audioMutex.Lock()
-	for id, ch := range c.audioSubscribers {
-		close(ch)
-		delete(c.audioSubscribers, id)
+// recordingStatus 获取当前录像编码方式
+func (c *FFmpegCapturer) recordingStatus() RecordingStatus {
+	c.recordingMu.RLock()
+	defer c.recordingMu.RUnlock()
+	if c.recording == nil {
+		return RecordingStatus{Mode: RecordingTranscode}
 	}
-	c.audioMutex.Unlock()
+	return *c.recording
+}
 
-	c.mutex.Lock()
-	c.running = false
-	c.mutex.Unlock()
+// SetRecordingConfig 设置录制配置
+func (c *FFmpegCapturer) SetRecordingConfig(cfg RecordingConfig) {
+	c.recordingConfig = &cfg
+}
 
-	log.Printf("音视频采集器 %s (%s) 已停止", c.config.Name, c.config.ID)
-	return nil
+// runCapture 运行一次采集管线，管线自身失败时切换 RTSP 备用地址
+func (c *FFmpegCapturer) runCapture(ctx context.Context) error {
+	startedAt := time.Now()
+	err := c.runPipeline(ctx)
+	c.failoverAfterRun(time.Since(startedAt))
+	return err
 }
 
-// startCapture 启动采集
-func (c *FFmpegCapturer) startCapture() error {
-	// 创建 MJPEG 管道
-	mjpegPipeR, mjpegPipeW, err := os.Pipe()
-	if err != nil {
-		return fmt.Errorf("创建 MJPEG 管道失败: %w", err)
+// failoverAfterRun 管线无法打开、异常退出或画面停滞时切换地址；
+// 停止采集器或 rerun 主动结束（如切换隐私模式）时源本身正常，不切换
+func (c *FFmpegCapturer) failoverAfterRun(ranFor time.Duration) {
+	if c.ctx.Err() != nil || c.supervisor.rerunRequested() {
+		return
 	}
-	c.mjpegPipe = mjpegPipeR
+	c.failover(ranFor)
+}
 
-	// 创建音频管道（如果启用音频）
-	var audioPipeR, audioPipeW *os.File
-	if c.config.Audio.Enabled {
-		audioPipeR, audioPipeW, err = os.Pipe()
-		if err != nil {
-			mjpegPipeR.Close()
-			mjpegPipeW.Close()
-			return fmt.Errorf("创建音频管道失败: %w", err)
-		}
-		c.audioPipe = audioPipeR
+// runPipeline 运行一次 FFmpeg 采集进程，阻塞直到进程退出、管道 EOF 或 ctx 取消
+func (c *FFmpegCapturer) runPipeline(ctx context.Context) error {
+	c.updateRecordingMode(ctx)
+
+	cmd, videoPipes, audioPipe, err := c.startCapture()
+	if err != nil {
+		return err
 	}
+	defer func() {
+		for _, pipe := range videoPipes {
+			pipe.Close()
+		}
+		if audioPipe != nil {
+			audioPipe.Close()
+		}
+		c.cmdMutex.Lock()
+		c.cmd = nil
+		c.cmdMutex.Unlock()
+	}()
 
-	// 构建 FFmpeg 参数
-	args := c.buildCaptureArgs(mjpegPipeW, audioPipeW)
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+	}()
 
-	c.cmdMutex.Lock()
-	c.cmd = exec.CommandContext(c.ctx, "ffmpeg", args...)
-	if c.config.Audio.Enabled {
-		c.cmd.ExtraFiles = []*os.File{mjpegPipeW, audioPipeW} // fd 3, fd 4
-	} else {
-		c.cmd.ExtraFiles = []*os.File{mjpegPipeW} // fd 3
+	// 启动各档位的 MJPEG 帧读取 goroutine，管道 EOF 时返回；以 main 档位判断管道关闭
+	streamDone := make(chan struct{})
+	go func() {
+		defer close(streamDone)
+		c.readMJPEGStream(ctx, videoPipes[0], c.outputs[0].name)
+	}()
+	for i, pipe := range videoPipes[1:] {
+		go c.readMJPEGStream(ctx, pipe, c.outputs[i+1].name)
+	}
+
+	// 启动音频读取 goroutine
+	if audioPipe != nil {
+		go c.readAudioStream(ctx, audioPipe)
 	}
-	c.cmd.Stderr = os.Stderr // 调试输出
-	c.cmdMutex.Unlock()
 
-	if err := c.cmd.Start(); err != nil {
-		mjpegPipeR.Close()
-		mjpegPipeW.Close()
-		if audioPipeR != nil {
-			audioPipeR.Close()
-			audioPipeW.Close()
+	select {
+	case <-ctx.Done():
+		stopProcess(cmd, waitCh)
+		return ctx.Err()
+	case err := <-waitCh:
+		// 进程退出后写端关闭，等待读取 goroutine 读到 EOF
+		<-streamDone
+		if err == nil {
+			return fmt.Errorf("FFmpeg 进程已退出")
 		}
-		return fmt.Errorf("启动 FFmpeg 失败: %w", err)
+		return fmt.Errorf("FFmpeg 进程已退出: %w", err)
+	case <-streamDone:
+		// 预览管道已关闭但进程可能仍挂起，结束进程后重启
+		stopProcess(cmd, waitCh)
+		return fmt.Errorf("MJPEG 管道已关闭")
 	}
+}
 
-	// 关闭写端（FFmpeg 进程已持有）
-	mjpegPipeW.Close()
-	if audioPipeW != nil {
-		audioPipeW.Close()
+// stopProcess 先发送中断信号让 FFmpeg 收尾，超时后强制结束
+func stopProcess(cmd *exec.Cmd, waitCh <-chan error) {
+	if cmd.Process == nil {
+		return
 	}
+	cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-waitCh:
+		return
+	case <-time.After(gracefulStopTimeout):
+	}
+	cmd.Process.Kill()
+	<-waitCh
+}
 
-	// 启动 MJPEG 帧读取 goroutine
-	go c.readMJPEGStream()
+// startCapture 启动 FFmpeg 进程，返回进程、各档位 MJPEG 管道读端（与 outputs 顺序一致）和音频管道读端
+// 管道按顺序作为 fd 3、4... 传给 FFmpeg：先是各档位 MJPEG，最后是音频
+func (c *FFmpegCapturer) startCapture() (*exec.Cmd, []*os.File, *os.File, error) {
+	var readers, writers []*os.File
+	closeAll := func() {
+		for _, f := range readers {
+			f.Close()
+		}
+		for _, f := range writers {
+			f.Close()
+		}
+	}
 
-	// 启动音频读取 goroutine
+	// 创建各档位 MJPEG 管道
+	for _, p := range c.outputs {
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeAll()
+			return nil, nil, nil, fmt.Errorf("创建 MJPEG 管道 %s 失败: %w", p.name, err)
+		}
+		readers = append(readers, r)
+		writers = append(writers, w)
+	}
+
+	// 创建音频管道（如果启用音频）
+	var audioPipeR *os.File
 	if c.config.Audio.Enabled {
-		go c.readAudioStream()
+		r, w, err := os.Pipe()
+		if err != nil {
+			closeAll()
+			return nil, nil, nil, fmt.Errorf("创建音频管道失败: %w", err)
+		}
+		audioPipeR = r
+		readers = append(readers, r)
+		writers = append(writers, w)
 	}
 
-	// 监控进程退出
-	go func() {
-		c.cmd.Wait()
-		c.cmdMutex.Lock()
-		c.cmd = nil
-		c.cmdMutex.Unlock()
-	}()
+	// 写入隐私遮挡图
+	if len(c.config.PrivacyMasks) > 0 {
+		if err := writePrivacyMaskImage(c.config.PrivacyMasks, privacyMaskPath(c.config.ID)); err != nil {
+			closeAll()
+			return nil, nil, nil, err
+		}
+	}
 
-	return nil
-}
+	// 写入 OSD 文本
+	if c.osd != nil {
+		if err := c.osd.write(); err != nil {
+			closeAll()
+			return nil, nil, nil, err
+		}
+	}
 
-// stopCapture 停止采集
-func (c *FFmpegCapturer) stopCapture() {
-	c.cmdMutex.Lock()
-	defer c.cmdMutex.Unlock()
+	// 构建 FFmpeg 参数
+	args := c.buildCaptureArgs(audioPipeR != nil)
 
-	if c.cmd != nil && c.cmd.Process != nil {
-		c.cmd.Process.Signal(os.Interrupt)
-		time.Sleep(1 * time.Second)
-		c.cmd.Process.Kill()
-		c.cmd.Wait()
-		c.cmd = nil
-	}
+	cmd := exec.Command("ffmpeg", args...)
+	cmd.ExtraFiles = writers
+	cmd.Stderr = ffmpeglog.Writer(c.config.ID, ffmpeglog.PipelineCapture)
 
-	if c.mjpegPipe != nil {
-		c.mjpegPipe.Close()
-		c.mjpegPipe = nil
+	if err := cmd.Start(); err != nil {
+		closeAll()
+		return nil, nil, nil, fmt.Errorf("启动 FFmpeg 失败: %w", err)
 	}
 
-	if c.audioPipe != nil {
-		c.audioPipe.Close()
-		c.audioPipe = nil
+	// 关闭写端（FFmpeg 进程已持有）
+	for _, w := range writers {
+		w.Close()
 	}
+
+	c.cmdMutex.Lock()
+	c.cmd = cmd
+	c.cmdMutex.Unlock()
+
+	return cmd, readers[:len(c.outputs)], audioPipeR, nil
 }
 
 // buildCaptureArgs 构建 FFmpeg 参数
-func (c *FFmpegCapturer) buildCaptureArgs(mjpegPipeW *os.File, audioPipeW *os.File) []string {
-	var args []string
+func (c *FFmpegCapturer) buildCaptureArgs(withAudio bool) []string {
+	// 日志带级别标签，便于按级别过滤；不输出进度统计
+	args := []string{
+		"-hide_banner",
+		"-nostats",
+		"-loglevel", "level+info",
+	}
+
+	// 音频流映射（文件可能不含音频，使用可选映射）
+	audioMap := "0:a"
+	// 预览视频流映射（rtsp 子码流时为第二个输入）
+	previewMap := "0:v"
 
 	// 输入配置
 	switch c.config.Type {
 	case "rtsp":
-		args = append(args,
-			"-rtsp_transport", "tcp",
-			"-i", c.config.RTSPUrl,
-		)
+		switch {
+		case c.config.RTSP.SubURL == "":
+			args = append(args, rtspInputArgs(c.config, c.currentSource())...)
+		case c.recordingConfig != nil:
+			// 主码流只用于录像和音频，预览解码低分辨率子码流
+			args = append(args, rtspInputArgs(c.config, c.currentSource())...)
+			args = append(args, rtspInputArgs(c.config, c.config.RTSP.SubURL)...)
+			previewMap = "1:v"
+		default:
+			// 不录像时无需拉取主码流
+			args = append(args, rtspInputArgs(c.config, c.config.RTSP.SubURL)...)
+		}
 	case "hls":
 		// HLS/m3u8 流输入
 		args = append(args,
@@ -303,90 +511,176 @@ func (c *FFmpegCapturer) buildCaptureArgs(mjpegPipeW *os.File, audioPipeW *os.Fi
 			"-reconnect_delay_max", "5",
 			"-i", c.config.HLSUrl,
 		)
+	case "file":
+		// 本地视频文件：按原始速率播放并无限循环，作为虚拟摄像头
+		args = append(args,
+			"-re",
+			"-stream_loop", "-1",
+			"-i", c.config.FilePath,
+		)
+		audioMap = "0:a?"
+	case "testsrc":
+		// FFmpeg lavfi 合成测试图案（可选正弦波音频），无需真实设备
+		args = append(args, c.getTestSourceArgs()...)
+		audioMap = "1:a"
 	default:
-		args = append(args, c.getInputArgs()...)
-	}
-
-	// 输出 1: MJPEG 预览流 -> pipe:3
-	args = append(args,
-		"-map", "0:v",
-		"-an",
-		"-f", "mjpeg",
-		"-q:v", "5",
-		"-r", fmt.Sprintf("%d", c.config.FPS),
-		"-s", fmt.Sprintf("%dx%d", c.config.Width, c.config.Height),
-		"pipe:3",
-	)
-
-	// 输出 2: 音频流 -> pipe:4 (PCM S16LE 48kHz mono，用于 WebRTC)
-	if c.config.Audio.Enabled && audioPipeW != nil {
+		inputArgs, deviceAudioMap := c.getInputArgs()
+		args = append(args, inputArgs...)
+		audioMap = deviceAudioMap
+	}
+
+	// 各预览档位和录像使用的视频流
+	previewMaps := make([]string, len(c.outputs))
+	for i := range previewMaps {
+		previewMaps[i] = previewMap
+	}
+	recordMap := "0:v"
+
+	// 画面变换：预览和录像使用同样的几何
+	filter := videoFilter{transform: c.config.Transform.Filters(), maskInput: -1}
+	// 隐私遮挡：遮挡图作为最后一个输入，叠加到预览和录像的源画面上
+	if len(c.config.PrivacyMasks) > 0 {
+		filter.maskInput = countInputs(args)
+		args = append(args, "-i", privacyMaskPath(c.config.ID))
+	}
+	// OSD：在遮挡之后叠加，可只叠加到录像
+	if c.osd != nil && (!c.osd.cfg.RecordingOnly || c.recordingConfig != nil) {
+		filter.osd = c.osd.filter()
+		filter.osdRecordOnly = c.osd.cfg.RecordingOnly
+	}
+	if !filter.empty() {
+		record := ""
+		if c.recordingConfig != nil {
+			record = recordMap
+		}
+		var graph string
+		graph, previewMaps, recordMap = filter.graph(previewMap, len(c.outputs), record)
+		args = append(args, "-filter_complex", graph)
+	}
+
+	// 输出 1: 各档位 MJPEG 预览流 -> pipe:3, pipe:4...
+	fd := 3
+	for i, p := range c.outputs {
+		args = append(args,
+			"-map", previewMaps[i],
+			"-an",
+			"-f", "mjpeg",
+			"-q:v", fmt.Sprintf("%d", p.quality),
+			"-r", fmt.Sprintf("%d", p.fps),
+			"-s", fmt.Sprintf("%dx%d", p.width, p.height),
+			fmt.Sprintf("pipe:%d", fd),
+		)
+		fd++
+	}
+
+	// 输出 2: 音频流 -> 最后一个管道 (PCM S16LE 48kHz mono，用于 WebRTC)
+	if withAudio {
 		args = append(args,
-			"-map", "0:a",
+			"-map", audioMap,
 			"-vn",
 			"-f", "s16le",
 			"-acodec", "pcm_s16le",
-			"-ar", "48000",
-			"-ac", "1",
-			"pipe:4",
+			"-ar", fmt.Sprintf("%d", audioSampleRate),
+			"-ac", fmt.Sprintf("%d", audioChannels),
+			fmt.Sprintf("pipe:%d", fd),
 		)
 	}
 
 	// 输出 3: 分段录像文件（如果配置了录制）
 	if c.recordingConfig != nil {
-		// 确保目录存在
-		outputDir := filepath.Join(c.recordingConfig.OutputPath, c.config.ID)
-		os.MkdirAll(outputDir, 0755)
-
-		// 文件名模板
-		outputPattern := filepath.Join(outputDir, c.config.ID+"_%Y%m%d_%H%M%S."+c.recordingConfig.Format)
-
+		recording := c.recordingStatus()
+		args = append(args, "-map", recordMap)
+		if recording.Mode == RecordingCopy {
+			// 直接复制源码流，不解码不编码
+			args = append(args, "-c:v", "copy")
+			if recording.VideoCodec == "hevc" && c.recordingConfig.Format != "mkv" {
+				// H.265 使用 hvc1 标签，兼容浏览器和 Apple 设备播放
+				args = append(args, "-tag:v", "hvc1")
+			}
+		} else {
+			args = append(args, c.recordEncoder.VideoArgs(c.config.FPS)...)
+		}
 		if c.config.Audio.Enabled {
 			// 有音频的录制
-			args = append(args,
-				"-map", "0:v",
-				"-map", "0:a",
-				"-c:v", "libx264",
-				"-pix_fmt", "yuv420p",
-				"-preset", "ultrafast",
-				"-crf", "23",
-				"-g", "60", // 关键帧间隔 2 秒（30fps * 2）
-				"-c:a", "aac",
-				"-b:a", "128k",
-				"-f", "segment",
-				"-segment_time", fmt.Sprintf("%d", c.recordingConfig.SegmentDuration),
-				"-segment_format", c.recordingConfig.Format,
-				// Fragmented MP4: 每个关键帧写入一个片段，异常中断也能保留已录制内容
-				"-segment_format_options", "movflags=frag_keyframe+empty_moov+default_base_moof",
-				"-reset_timestamps", "1",
-				"-strftime", "1",
-				outputPattern,
-			)
+			args = append(args, "-map", audioMap)
+			if recording.CopyAudio {
+				args = append(args, "-c:a", "copy")
+			} else {
+				args = append(args, c.recordEncoder.AudioArgs()...)
+			}
 		} else {
 			// 无音频的录制
-			args = append(args,
-				"-map", "0:v",
-				"-an",
-				"-c:v", "libx264",
-				"-pix_fmt", "yuv420p",
-				"-preset", "ultrafast",
-				"-crf", "23",
-				"-g", "60", // 关键帧间隔 2 秒
-				"-f", "segment",
-				"-segment_time", fmt.Sprintf("%d", c.recordingConfig.SegmentDuration),
-				"-segment_format", c.recordingConfig.Format,
-				"-segment_format_options", "movflags=frag_keyframe+empty_moov+default_base_moof",
-				"-reset_timestamps", "1",
-				"-strftime", "1",
-				outputPattern,
-			)
+			args = append(args, "-an")
 		}
+		args = append(args, segmentOutputArgs(c.recordingConfig, c.config.ID)...)
 	}
 
 	return args
 }
 
-// getInputArgs 获取输入参数
-func (c *FFmpegCapturer) getInputArgs() []string {
+// setOSDText 更新 OSD 动态文本，drawtext 下一帧起生效
+func (c *FFmpegCapturer) setOSDText(text string) error {
+	if c.osd == nil {
+		return fmt.Errorf("未启用 OSD")
+	}
+	return c.osd.setText(text)
+}
+
+// countInputs 统计参数中的输入数量
+func countInputs(args []string) int {
+	n := 0
+	for _, arg := range args {
+		if arg == "-i" {
+			n++
+		}
+	}
+	return n
+}
+
+// rtspInputArgs RTSP 输入参数（传输方式、超时、认证），采集和 ffprobe 探测共用
+func rtspInputArgs(cfg config.CameraConfig, rawURL string) []string {
+	var args []string
+
+	transport := cfg.RTSP.Transport
+	if transport == "" {
+		transport = "tcp"
+	}
+	if transport != "auto" {
+		args = append(args, "-rtsp_transport", transport)
+	}
+	if cfg.RTSP.Timeout > 0 {
+		// 套接字读写超时，单位微秒
+		args = append(args, "-timeout", fmt.Sprintf("%d", cfg.RTSP.Timeout*1000000))
+	}
+
+	return append(args, "-i", config.WithCredentials(rawURL, cfg.Username, cfg.Password))
+}
+
+// segmentOutputArgs 生成分段录像输出参数（不含输入映射与编码参数）
+// 复制码流时只能在源关键帧处分段，实际分段时长取决于摄像头的关键帧间隔
+func segmentOutputArgs(recCfg *RecordingConfig, cameraID string) []string {
+	// 确保目录存在
+	outputDir := filepath.Join(recCfg.OutputPath, cameraID)
+	os.MkdirAll(outputDir, 0755)
+
+	// 文件名模板
+	outputPattern := filepath.Join(outputDir, cameraID+"_%Y%m%d_%H%M%S."+recCfg.Format)
+
+	return []string{
+		"-f", "segment",
+		"-segment_time", fmt.Sprintf("%d", recCfg.SegmentDuration),
+		"-segment_format", recCfg.Format,
+		// Fragmented MP4: 每个关键帧写入一个片段，异常中断也能保留已录制内容
+		"-segment_format_options", "movflags=frag_keyframe+empty_moov+default_base_moof",
+		"-reset_timestamps", "1",
+		"-strftime", "1",
+		outputPattern,
+	}
+}
+
+// getInputArgs 获取设备输入参数及音频流映射
+// Linux 下音视频为两个独立输入，音频位于输入 1；其余平台为同一输入
+func (c *FFmpegCapturer) getInputArgs() ([]string, string) {
 	switch runtime.GOOS {
 	case "darwin":
 		if c.config.Audio.Enabled {
@@ -396,14 +690,14 @@ func (c *FFmpegCapturer) getInputArgs() []string {
 				"-framerate", fmt.Sprintf("%d", c.config.FPS),
 				"-video_size", fmt.Sprintf("%dx%d", c.config.Width, c.config.Height),
 				"-i", deviceInput,
-			}
+			}, "0:a"
 		}
 		return []string{
 			"-f", "avfoundation",
 			"-framerate", fmt.Sprintf("%d", c.config.FPS),
 			"-video_size", fmt.Sprintf("%dx%d", c.config.Width, c.config.Height),
 			"-i", fmt.Sprintf("%d:none", c.config.DeviceIndex),
-		}
+		}, "0:a"
 
 	case "linux":
 		args := []string{
@@ -414,12 +708,16 @@ func (c *FFmpegCapturer) getInputArgs() []string {
 		}
 		if c.config.Audio.Enabled {
 			if c.config.Audio.Type == "pulse" {
-				args = append(args, "-f", "pulse", "-i", "default")
+				source := "default"
+				if c.config.Audio.DeviceName != "" {
+					source = c.config.Audio.DeviceName
+				}
+				args = append(args, "-f", "pulse", "-i", source)
 			} else {
 				args = append(args, "-f", "alsa", "-i", fmt.Sprintf("hw:%d", c.config.Audio.DeviceIndex))
 			}
 		}
-		return args
+		return args, "1:a"
 
 	case "windows":
 		if c.config.Audio.Enabled {
@@ -431,171 +729,81 @@ func (c *FFmpegCapturer) getInputArgs() []string {
 			return []string{
 				"-f", "dshow",
 				"-i", fmt.Sprintf("%s:audio=%s", videoDevice, audioDevice),
-			}
+			}, "0:a"
 		}
 		return []string{
 			"-f", "dshow",
 			"-i", fmt.Sprintf("video=@device_pnp_%d", c.config.DeviceIndex),
-		}
+		}, "0:a"
 
 	default:
 		return []string{
 			"-f", "v4l2",
 			"-i", fmt.Sprintf("/dev/video%d", c.config.DeviceIndex),
-		}
+		}, "0:a"
 	}
 }
 
-// readMJPEGStream 读取 MJPEG 预览流
-func (c *FFmpegCapturer) readMJPEGStream() {
-	defer func() {
-		select {
-		case <-c.done:
-		default:
-			close(c.done)
-		}
-	}()
+// getTestSourceArgs 获取 lavfi 测试图案输入参数
+// 输入 0 为视频图案（可叠加时钟），启用音频时输入 1 为正弦波
+func (c *FFmpegCapturer) getTestSourceArgs() []string {
+	pattern := c.config.TestPattern.Pattern
+	if pattern == "" {
+		pattern = "testsrc2"
+	}
+	video := fmt.Sprintf("%s=size=%dx%d:rate=%d", pattern, c.config.Width, c.config.Height, c.config.FPS)
+	if c.config.TestPattern.Clock {
+		video += ",drawtext=text='%{localtime\\:%Y-%m-%d %X}':fontcolor=white:fontsize=32:box=1:boxcolor=black@0.5:x=10:y=10"
+	}
 
-	reader := bufio.NewReaderSize(c.mjpegPipe, 1024*1024)
-	jpegStart := []byte{0xFF, 0xD8}
-	jpegEnd := []byte{0xFF, 0xD9}
-	var frameBuffer []byte
+	args := []string{
+		"-re",
+		"-f", "lavfi",
+		"-i", video,
+	}
 
+	if c.config.Audio.Enabled {
+		args = append(args,
+			"-re",
+			"-f", "lavfi",
+			"-i", fmt.Sprintf("sine=frequency=%d:sample_rate=48000", c.config.TestPattern.ToneFrequency),
+		)
+	}
+	return args
+}
+
+// readMJPEGStream 读取一个档位的 MJPEG 预览流，管道 EOF 或出错时返回
+func (c *FFmpegCapturer) readMJPEGStream(ctx context.Context, pipe io.Reader, profile string) {
+	parser := newJPEGParser(jpegMaxFrameSize, &c.parserCounters)
 	buffer := make([]byte, 64*1024)
 
+	emit := func(data []byte) {
+		frame := newFrame(data)
+		c.broadcastProfileFrame(profile, frame)
+		frame.Release()
+	}
+
 	for {
 		select {
-		case <-c.ctx.Done():
+		case <-ctx.Done():
 			return
 		default:
-			n, err := reader.Read(buffer)
+			n, err := pipe.Read(buffer)
+			if n > 0 {
+				parser.Write(buffer[:n], emit)
+			}
 			if err != nil {
-				if err != io.EOF && c.ctx.Err() == nil {
+				if err != io.EOF && ctx.Err() == nil {
 					log.Printf("读取 MJPEG 流错误: %v", err)
 				}
 				return
 			}
-
-			frameBuffer = append(frameBuffer, buffer[:n]...)
-
-			// 解析 JPEG 帧
-			for {
-				startIdx := findBytes(frameBuffer, jpegStart)
-				if startIdx == -1 {
-					break
-				}
-
-				endIdx := findBytes(frameBuffer[startIdx:], jpegEnd)
-				if endIdx == -1 {
-					break
-				}
-
-				endIdx += startIdx + 2
-
-				frame := make([]byte, endIdx-startIdx)
-				copy(frame, frameBuffer[startIdx:endIdx])
-
-				c.lastFrameMu.Lock()
-				c.lastFrame = frame
-				c.lastFrameMu.Unlock()
-
-				c.broadcastFrame(frame)
-
-				frameBuffer = frameBuffer[endIdx:]
-			}
-
-			// 防止缓冲区过大
-			if len(frameBuffer) > 2*1024*1024 {
-				frameBuffer = frameBuffer[len(frameBuffer)-1024*1024:]
-			}
 		}
 	}
 }
 
-// broadcastFrame 广播帧数据给订阅者
-func (c *FFmpegCapturer) broadcastFrame(frame []byte) {
-	c.frameMutex.RLock()
-	defer c.frameMutex.RUnlock()
-
-	for _, ch := range c.frameSubscribers {
-		frameCopy := make([]byte, len(frame))
-		copy(frameCopy, frame)
-
-		select {
-		case ch <- frameCopy:
-		default:
-			// 缓冲区满，丢弃旧帧
-			select {
-			case <-ch:
-			default:
-			}
-			select {
-			case ch <- frameCopy:
-			default:
-			}
-		}
-	}
-}
-
-// GetFrame 获取当前帧
-func (c *FFmpegCapturer) GetFrame() ([]byte, error) {
-	c.mutex.RLock()
-	running := c.running
-	c.mutex.RUnlock()
-
-	if !running {
-		return nil, fmt.Errorf("采集器未运行")
-	}
-
-	c.lastFrameMu.RLock()
-	frame := c.lastFrame
-	c.lastFrameMu.RUnlock()
-
-	if frame != nil {
-		result := make([]byte, len(frame))
-		copy(result, frame)
-		return result, nil
-	}
-
-	subID := fmt.Sprintf("snapshot_%d", time.Now().UnixNano())
-	ch := c.SubscribeFrames(subID)
-	defer c.UnsubscribeFrames(subID)
-
-	select {
-	case frame := <-ch:
-		return frame, nil
-	case <-time.After(3 * time.Second):
-		return nil, fmt.Errorf("获取帧超时")
-	}
-}
-
-// SubscribeFrames 订阅帧数据
-func (c *FFmpegCapturer) SubscribeFrames(id string) <-chan []byte {
-	c.frameMutex.Lock()
-	defer c.frameMutex.Unlock()
-
-	ch := make(chan []byte, 30)
-	c.frameSubscribers[id] = ch
-	return ch
-}
-
-// UnsubscribeFrames 取消订阅帧数据
-func (c *FFmpegCapturer) UnsubscribeFrames(id string) {
-	c.frameMutex.Lock()
-	defer c.frameMutex.Unlock()
-
-	if ch, exists := c.frameSubscribers[id]; exists {
-		close(ch)
-		delete(c.frameSubscribers, id)
-	}
-}
-
-// readAudioStream 读取音频流
-func (c *FFmpegCapturer) readAudioStream() {
-	if c.audioPipe == nil {
-		return
-	}
-
+// readAudioStream 读取音频流，管道 EOF 或出错时返回
+func (c *FFmpegCapturer) readAudioStream(ctx context.Context, pipe io.Reader) {
 	// 960 samples * 2 bytes * 1 channel = 1920 bytes = 20ms of audio at 48kHz
 	// Opus 通常使用 20ms 帧
 	const audioFrameSize = 960 * 2 * 1 // 1920 bytes per 20ms frame
@@ -603,12 +811,12 @@ func (c *FFmpegCapturer) readAudioStream() {
 
 	for {
 		select {
-		case <-c.ctx.Done():
+		case <-ctx.Done():
 			return
 		default:
-			n, err := io.ReadFull(c.audioPipe, buffer)
+			n, err := io.ReadFull(pipe, buffer)
 			if err != nil {
-				if err != io.EOF && c.ctx.Err() == nil {
+				if err != io.EOF && err != io.ErrUnexpectedEOF && ctx.Err() == nil {
 					log.Printf("读取音频流错误: %v", err)
 				}
 				return
@@ -621,78 +829,124 @@ func (c *FFmpegCapturer) readAudioStream() {
 	}
 }
 
-// broadcastAudio 广播音频数据给订阅者
-func (c *FFmpegCapturer) broadcastAudio(audio []byte) {
-	c.audioMutex.RLock()
-	defer c.audioMutex.RUnlock()
+// Manager 采集器管理器
+type Manager struct {
+	capturers map[string]AVCapturer
+	mutex     sync.RWMutex
 
-	for _, ch := range c.audioSubscribers {
-		audioCopy := make([]byte, len(audio))
-		copy(audioCopy, audio)
+	// 生命周期事件监听者
+	listeners     []Listener
+	listenerMutex sync.RWMutex
 
-		select {
-		case ch <- audioCopy:
-		default:
-			// 缓冲区满，丢弃
-		}
-	}
-}
+	// 单路启停操作串行执行
+	lifecycleMutex sync.Mutex
+
+	// StartAll 传入的长期 context，单路启动时复用
+	ctx context.Context
 
-// SubscribeAudio 订阅音频数据
-func (c *FFmpegCapturer) SubscribeAudio(id string) <-chan []byte {
-	c.audioMutex.Lock()
-	defer c.audioMutex.Unlock()
+	// 创建采集器时使用的默认参数（录制配置、预览质量）
+	options captureOptions
 
-	ch := make(chan []byte, 100) // 缓冲 100 个 20ms 帧 = 2秒
-	c.audioSubscribers[id] = ch
-	return ch
+	// 各摄像头 OSD 的动态文本和隐私模式手动设置，采集器重建后保留
+	osdTexts     map[string]string
+	privacyModes map[string]PrivacyMode
 }
 
-// UnsubscribeAudio 取消订阅音频数据
-func (c *FFmpegCapturer) UnsubscribeAudio(id string) {
-	c.audioMutex.Lock()
-	defer c.audioMutex.Unlock()
+// NewManager 创建采集器管理器
+func NewManager() *Manager {
+	return &Manager{
+		capturers:    make(map[string]AVCapturer),
+		osdTexts:     make(map[string]string),
+		privacyModes: make(map[string]PrivacyMode),
+	}
+}
 
-	if ch, exists := c.audioSubscribers[id]; exists {
-		close(ch)
-		delete(c.audioSubscribers, id)
+// optionsFor 创建指定摄像头采集器的参数，需持有 m.mutex
+func (m *Manager) optionsFor(id string) captureOptions {
+	opts := m.options
+	opts.osdText = m.osdTexts[id]
+	opts.privacyMode = m.privacyModes[id]
+	if opts.privacyMode == "" {
+		opts.privacyMode = PrivacyAuto
 	}
+	return opts
 }
 
-// findBytes 查找字节序列
-func findBytes(data, pattern []byte) int {
-	if len(pattern) == 0 || len(data) < len(pattern) {
-		return -1
+// SetPrivacyMode 手动设置摄像头的隐私模式，auto 表示按计划时段
+// 设置保存在管理器中，采集器重建后保留
+func (m *Manager) SetPrivacyMode(id string, mode PrivacyMode) error {
+	m.mutex.Lock()
+	capturer, exists := m.capturers[id]
+	if !exists {
+		m.mutex.Unlock()
+		return fmt.Errorf("采集器 %s 不存在", id)
 	}
-	for i := 0; i <= len(data)-len(pattern); i++ {
-		match := true
-		for j := 0; j < len(pattern); j++ {
-			if data[i+j] != pattern[j] {
-				match = false
-				break
-			}
-		}
-		if match {
-			return i
-		}
+	if mode == PrivacyAuto {
+		delete(m.privacyModes, id)
+	} else {
+		m.privacyModes[id] = mode
 	}
-	return -1
-}
+	m.mutex.Unlock()
 
-// Manager 采集器管理器
-type Manager struct {
-	capturers map[string]AVCapturer
-	mutex     sync.RWMutex
+	capturer.SetPrivacyMode(mode)
+	return nil
 }
 
-// NewManager 创建采集器管理器
-func NewManager() *Manager {
-	return &Manager{
-		capturers: make(map[string]AVCapturer),
+// SetOSDText 设置摄像头 OSD 的动态文本（如 "ARMED"），空字符串表示清除
+// 立即更新运行中的画面，不重启采集
+func (m *Manager) SetOSDText(id, text string) error {
+	m.mutex.Lock()
+	capturer, exists := m.capturers[id]
+	if !exists {
+		m.mutex.Unlock()
+		return fmt.Errorf("采集器 %s 不存在", id)
+	}
+	if !capturer.GetConfig().OSD.Enabled {
+		m.mutex.Unlock()
+		return fmt.Errorf("摄像头 %s 未启用 OSD", id)
+	}
+	if text == "" {
+		delete(m.osdTexts, id)
+	} else {
+		m.osdTexts[id] = text
 	}
+	m.mutex.Unlock()
+
+	if setter, ok := capturer.(osdTextSetter); ok {
+		return setter.setOSDText(text)
+	}
+	return nil
+}
+
+// OSDText 获取摄像头 OSD 的动态文本
+func (m *Manager) OSDText(id string) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.osdTexts[id]
+}
+
+// SetRecordingConfig 设置默认录制配置，之后添加的采集器都会录像
+func (m *Manager) SetRecordingConfig(recCfg RecordingConfig) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.options.recording = &recCfg
+}
+
+// SetEncoderProfiles 设置编码档位，之后添加的采集器按摄像头配置选择录像转码档位
+func (m *Manager) SetEncoderProfiles(encoders config.EncoderProfiles) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.options.encoders = encoders
 }
 
-// AddCapturer 添加采集器
+// SetPreviewQuality 设置主预览档位的 JPEG 质量（1-31，越小越好），之后添加的采集器生效
+func (m *Manager) SetPreviewQuality(quality int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.options.previewQuality = quality
+}
+
+// AddCapturer 添加采集器（设置了默认录制配置时同时录像）
 func (m *Manager) AddCapturer(cfg config.CameraConfig) (AVCapturer, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -701,7 +955,7 @@ func (m *Manager) AddCapturer(cfg config.CameraConfig) (AVCapturer, error) {
 		return nil, fmt.Errorf("采集器 %s 已存在", cfg.ID)
 	}
 
-	capturer := NewAVCapturer(cfg)
+	capturer := newCapturer(cfg, m.optionsFor(cfg.ID))
 	m.capturers[cfg.ID] = capturer
 	log.Printf("已添加采集器: %s (%s)", cfg.Name, cfg.ID)
 	return capturer, nil
@@ -716,13 +970,9 @@ func (m *Manager) AddCapturerWithRecording(cfg config.CameraConfig, recCfg Recor
 		return nil, fmt.Errorf("采集器 %s 已存在", cfg.ID)
 	}
 
-	capturer := &FFmpegCapturer{
-		config:           cfg,
-		frameSubscribers: make(map[string]chan []byte),
-		audioSubscribers: make(map[string]chan []byte),
-		done:             make(chan struct{}),
-		recordingConfig:  &recCfg,
-	}
+	opts := m.optionsFor(cfg.ID)
+	opts.recording = &recCfg
+	capturer := newCapturer(cfg, opts)
 	m.capturers[cfg.ID] = capturer
 	log.Printf("已添加采集器（带录制）: %s (%s)", cfg.Name, cfg.ID)
 	return capturer, nil
@@ -754,6 +1004,10 @@ func (m *Manager) GetAllCapturers() []AVCapturer {
 
 // StartAll 启动所有采集器
 func (m *Manager) StartAll(ctx context.Context) error {
+	m.mutex.Lock()
+	m.ctx = ctx
+	m.mutex.Unlock()
+
 	capturers := m.GetAllCapturers()
 	for _, c := range capturers {
 		if err := c.Start(ctx); err != nil {
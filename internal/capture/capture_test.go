@@ -0,0 +1,163 @@
+package capture
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"home-monitor/internal/config"
+)
+
+// newTestRTSPCapturer 创建带一个备用地址的 RTSP 采集器，管线替换为阻塞到 ctx 取消的占位运行
+// 每次启动管线向返回的通道发送一次
+func newTestRTSPCapturer(t *testing.T, stallTimeout int) (*FFmpegCapturer, <-chan struct{}) {
+	t.Helper()
+	cfg := config.CameraConfig{
+		ID:           "cam1",
+		Type:         "rtsp",
+		RTSPUrl:      "rtsp://primary/stream",
+		FPS:          5,
+		StallTimeout: stallTimeout,
+	}
+	cfg.RTSP.FallbackURLs = []string{"rtsp://fallback/stream"}
+
+	c := newFFmpegCapturer(cfg, captureOptions{privacyMode: PrivacyAuto})
+	runs := make(chan struct{}, 16)
+	c.run = func(ctx context.Context) error {
+		runs <- struct{}{}
+		startedAt := time.Now()
+		<-ctx.Done()
+		c.failoverAfterRun(time.Since(startedAt))
+		return ctx.Err()
+	}
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Stop() })
+	return c, runs
+}
+
+// waitRun 等待下一次启动管线
+func waitRun(t *testing.T, runs <-chan struct{}, timeout time.Duration) {
+	t.Helper()
+	select {
+	case <-runs:
+	case <-time.After(timeout):
+		t.Fatal("管线未重新启动")
+	}
+}
+
+// TestPrivacyToggleKeepsSource 切换隐私模式主动结束的运行不切换 RTSP 地址
+func TestPrivacyToggleKeepsSource(t *testing.T) {
+	c, runs := newTestRTSPCapturer(t, 0)
+	waitRun(t, runs, time.Second)
+
+	for i := 0; i < 3; i++ {
+		c.SetPrivacyMode(PrivacyOn)
+		c.SetPrivacyMode(PrivacyOff)
+		waitRun(t, runs, time.Second)
+	}
+
+	c.sourceMu.RLock()
+	index := c.sourceIndex
+	c.sourceMu.RUnlock()
+	if index != 0 {
+		t.Fatalf("切换隐私模式后 sourceIndex = %d, want 0", index)
+	}
+}
+
+// TestStallFailsOver 画面停滞结束的运行切换到备用地址
+func TestStallFailsOver(t *testing.T) {
+	c, runs := newTestRTSPCapturer(t, 1)
+	waitRun(t, runs, time.Second)
+	waitRun(t, runs, 5*time.Second)
+
+	if got := c.currentSource(); got != "rtsp://fallback/stream" {
+		t.Fatalf("停滞后 source = %s, want 备用地址", got)
+	}
+}
+
+func TestFailoverRetriesPrimary(t *testing.T) {
+	cfg := config.CameraConfig{ID: "cam1", Type: "rtsp", RTSPUrl: "rtsp://primary/stream", FPS: 5}
+	cfg.RTSP.FallbackURLs = []string{"rtsp://fallback1/stream", "rtsp://fallback2/stream"}
+
+	tests := []struct {
+		name   string
+		from   int
+		ranFor time.Duration
+		want   int
+	}{
+		{"主地址失败切换到备用", 0, time.Second, 1},
+		{"主地址稳定运行后失败也切换", 0, stableRunPeriod, 1},
+		{"备用地址很快失败切换到下一个", 1, time.Second, 2},
+		{"最后一个之后回到主地址", 2, time.Second, 0},
+		{"备用地址稳定运行后重试主地址", 1, stableRunPeriod, 0},
+		{"最后一个备用地址稳定运行后重试主地址", 2, 2 * stableRunPeriod, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newFFmpegCapturer(cfg, captureOptions{privacyMode: PrivacyAuto})
+			c.sourceIndex = tt.from
+			c.failover(tt.ranFor)
+			if c.sourceIndex != tt.want {
+				t.Errorf("sourceIndex = %d, want %d", c.sourceIndex, tt.want)
+			}
+		})
+	}
+}
+
+// TestSlowProbeNotStalled ffprobe 探测耗时超过 stall_timeout 时不触发停滞，探测结果被缓存
+func TestSlowProbeNotStalled(t *testing.T) {
+	// 用耗时 2 秒的假 ffprobe 代替真实探测
+	dir := t.TempDir()
+	script := "#!/bin/sh\nsleep 2\necho '{\"streams\":[{\"codec_type\":\"video\",\"codec_name\":\"h264\"}]}'\n"
+	if err := os.WriteFile(filepath.Join(dir, "ffprobe"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := config.CameraConfig{
+		ID:           "cam1",
+		Type:         "rtsp",
+		RTSPUrl:      "rtsp://primary/stream",
+		FPS:          5,
+		StallTimeout: 1,
+		RecordMode:   config.RecordModeAuto,
+	}
+	c := newFFmpegCapturer(cfg, captureOptions{
+		privacyMode: PrivacyAuto,
+		recording:   &RecordingConfig{Format: "mp4"},
+	})
+	probed := make(chan error, 1)
+	c.run = func(ctx context.Context) error {
+		c.updateRecordingMode(ctx)
+		probed <- ctx.Err()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	select {
+	case err := <-probed:
+		if err != nil {
+			t.Fatalf("探测期间运行被取消: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("探测未完成")
+	}
+
+	if status := c.GetStatus(); status.StallCount != 0 {
+		t.Fatalf("探测期间触发了停滞: stall_count = %d", status.StallCount)
+	}
+	c.recordingMu.RLock()
+	cached, ok := c.recordingModes[cfg.RTSPUrl]
+	c.recordingMu.RUnlock()
+	if !ok || cached.Mode != RecordingCopy || cached.VideoCodec != "h264" {
+		t.Fatalf("探测结果未缓存: %+v (ok=%v)", cached, ok)
+	}
+}
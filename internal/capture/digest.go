@@ -0,0 +1,141 @@
+package capture
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+)
+
+// digestAuth HTTP Digest 认证（RFC 7616，支持 MD5 / SHA-256 与 qop=auth）
+type digestAuth struct {
+	username  string
+	password  string
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+
+	// 同一 nonce 下的请求计数
+	nc    int
+	mutex sync.Mutex
+}
+
+// newDigestAuth 解析 WWW-Authenticate 质询
+func newDigestAuth(challenge, username, password string) (*digestAuth, error) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	if !strings.EqualFold(scheme, "Digest") {
+		return nil, fmt.Errorf("不支持的认证方式: %s", scheme)
+	}
+
+	params := parseAuthParams(rest)
+	d := &digestAuth{
+		username:  username,
+		password:  password,
+		realm:     params["realm"],
+		nonce:     params["nonce"],
+		opaque:    params["opaque"],
+		algorithm: params["algorithm"],
+	}
+	if d.nonce == "" {
+		return nil, fmt.Errorf("Digest 质询缺少 nonce")
+	}
+
+	// 仅支持 auth，服务器未声明 qop 时使用 RFC 2069 兼容模式
+	for _, q := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			d.qop = "auth"
+		}
+	}
+
+	switch strings.ToUpper(d.algorithm) {
+	case "", "MD5", "SHA-256":
+	default:
+		return nil, fmt.Errorf("不支持的 Digest 算法: %s", d.algorithm)
+	}
+	return d, nil
+}
+
+// authorize 生成 Authorization 头
+func (d *digestAuth) authorize(method, uri string) string {
+	d.mutex.Lock()
+	d.nc++
+	nc := fmt.Sprintf("%08x", d.nc)
+	d.mutex.Unlock()
+
+	ha1 := d.hash(d.username + ":" + d.realm + ":" + d.password)
+	ha2 := d.hash(method + ":" + uri)
+
+	var response, cnonce string
+	if d.qop == "auth" {
+		cnonce = newCnonce()
+		response = d.hash(strings.Join([]string{ha1, d.nonce, nc, cnonce, d.qop, ha2}, ":"))
+	} else {
+		response = d.hash(ha1 + ":" + d.nonce + ":" + ha2)
+	}
+
+	header := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s"`,
+		d.username, d.realm, d.nonce, uri, response)
+	if d.algorithm != "" {
+		header += ", algorithm=" + d.algorithm
+	}
+	if d.opaque != "" {
+		header += fmt.Sprintf(`, opaque="%s"`, d.opaque)
+	}
+	if d.qop == "auth" {
+		header += fmt.Sprintf(`, qop=auth, nc=%s, cnonce="%s"`, nc, cnonce)
+	}
+	return header
+}
+
+// hash 按质询指定的算法计算十六进制摘要
+func (d *digestAuth) hash(s string) string {
+	var h hash.Hash
+	if strings.EqualFold(d.algorithm, "SHA-256") {
+		h = sha256.New()
+	} else {
+		h = md5.New()
+	}
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newCnonce 生成客户端随机数
+func newCnonce() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// parseAuthParams 解析 key=value / key="value" 形式的认证参数
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, s = rest[1:], ""
+			} else {
+				value, s = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+		params[key] = value
+	}
+	return params
+}
@@ -0,0 +1,108 @@
+package capture
+
+import (
+	"fmt"
+	"strings"
+)
+
+// videoFilter 预览和录像画面共用的滤镜：画面变换、隐私遮挡与 OSD
+type videoFilter struct {
+	transform     string // 画面变换滤镜链，空表示无变换
+	maskInput     int    // 遮挡图的输入序号，< 0 表示无遮挡
+	osd           string // drawtext 滤镜，空表示无 OSD
+	osdRecordOnly bool   // OSD 只叠加到录像
+}
+
+// empty 是否无需滤镜
+func (f videoFilter) empty() bool {
+	return f.transform == "" && f.maskInput < 0 && f.osd == ""
+}
+
+// graph 构建 -filter_complex 滤镜图
+// preview 为预览源视频流及其输出数量，record 为录像源视频流（空表示不录像）
+// 按 变换 → 遮挡 → OSD 的顺序处理，遮挡图按变换后的分辨率缩放后叠加；预览与录像同源时只处理一次再分流
+// 返回滤镜图及各预览输出、录像输出的 -map 标签
+func (f videoFilter) graph(preview string, previews int, record string) (string, []string, string) {
+	type source struct {
+		stream   string
+		previews int
+		record   bool
+	}
+	sources := []source{{stream: preview, previews: previews}}
+	switch {
+	case record == "":
+	case record == preview:
+		sources[0].record = true
+	default:
+		sources = append(sources, source{stream: record, record: true})
+	}
+
+	var parts []string
+	masks := []string{fmt.Sprintf("[%d:v]", f.maskInput)}
+	if f.maskInput >= 0 && len(sources) > 1 {
+		parts = append(parts, fmt.Sprintf("[%d:v]split=2[mask0][mask1]", f.maskInput))
+		masks = []string{"[mask0]", "[mask1]"}
+	}
+
+	var previewLabels []string
+	recordLabel := ""
+	for i, s := range sources {
+		head := fmt.Sprintf("[%s]", s.stream)
+		var filters []string
+		if f.transform != "" {
+			if f.maskInput >= 0 {
+				// 遮挡图需按变换后的画面缩放，变换单独成链
+				parts = append(parts, fmt.Sprintf("%s%s[tf%d]", head, f.transform, i))
+				head = fmt.Sprintf("[tf%d]", i)
+			} else {
+				filters = append(filters, f.transform)
+			}
+		}
+		if f.maskInput >= 0 {
+			parts = append(parts, fmt.Sprintf("%s%sscale2ref[cover%d][src%d]", masks[i], head, i, i))
+			head = fmt.Sprintf("[src%d][cover%d]", i, i)
+			filters = append(filters, "overlay=format=auto")
+		}
+
+		// 只叠加到录像的 OSD 在分流后单独加到录像分支
+		recordOSD := f.osd != "" && f.osdRecordOnly && s.record && s.previews > 0
+		if f.osd != "" && (!f.osdRecordOnly || s.previews == 0) {
+			filters = append(filters, f.osd)
+		}
+
+		count := s.previews
+		if s.record {
+			count++
+		}
+		if len(filters) == 0 && count == 1 && !recordOSD {
+			// 该源无需处理，直接映射输入流
+			if s.record {
+				recordLabel = s.stream
+			} else {
+				previewLabels = append(previewLabels, s.stream)
+			}
+			continue
+		}
+
+		var outputs strings.Builder
+		for j := 0; j < s.previews; j++ {
+			label := fmt.Sprintf("[v%d_%d]", i, j)
+			outputs.WriteString(label)
+			previewLabels = append(previewLabels, label)
+		}
+		if s.record {
+			recordLabel = fmt.Sprintf("[rec%d]", i)
+			if recordOSD {
+				outputs.WriteString(fmt.Sprintf("[raw%d]", i))
+			} else {
+				outputs.WriteString(recordLabel)
+			}
+		}
+		filters = append(filters, fmt.Sprintf("split=%d%s", count, outputs.String()))
+		parts = append(parts, head+strings.Join(filters, ","))
+		if recordOSD {
+			parts = append(parts, fmt.Sprintf("[raw%d]%s%s", i, f.osd, recordLabel))
+		}
+	}
+	return strings.Join(parts, ";"), previewLabels, recordLabel
+}
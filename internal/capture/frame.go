@@ -0,0 +1,129 @@
+package capture
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 帧缓冲池参数
+const (
+	frameBufferAlign   = 64 * 1024       // 新分配的缓冲区按 64KB 对齐，便于不同大小的帧复用
+	frameBufferMaxPool = 8 * 1024 * 1024 // 超过该容量的缓冲区不回收，避免池中长期持有大内存
+)
+
+// monoEpoch 进程内单调时钟基准
+// 采集器用 now.Sub(monoEpoch) 计算 Mono，与 CapturedAt 取自同一次 time.Now()，不受系统校时影响
+var monoEpoch = time.Now()
+
+// FrameMeta 帧元数据，由采集器在广播前填写
+type FrameMeta struct {
+	CameraID   string        `json:"camera_id"`
+	Profile    string        `json:"profile"`     // 预览档位，main 为主档位
+	Seq        uint64        `json:"seq"`         // 档位内递增的帧序号，跨管线重启连续，跳号即丢帧
+	CapturedAt time.Time     `json:"captured_at"` // 采集时的墙上时间
+	Mono       time.Duration `json:"mono"`        // 采集时的进程内单调时钟，用于音视频对齐和延迟计算
+	Width      int           `json:"width"`       // 从 JPEG SOF 段读取，无法解析时为 0
+	Height     int           `json:"height"`
+}
+
+// Frame 不可变、引用计数的 JPEG 帧
+// 一帧数据只分配一次，由采集器广播给所有订阅者共享；
+// 持有者使用完毕后调用 Release，引用归零时缓冲区回收到池中。
+// Bytes 返回的切片只读，Release 之后不得再访问。
+type Frame struct {
+	data   []byte
+	meta   FrameMeta
+	refs   atomic.Int32
+	pooled bool // 缓冲区来自 framePool，引用归零时回收
+}
+
+// AudioChunk 带元数据的 PCM 音频块（s16le），广播后所有订阅者共享，只读
+type AudioChunk struct {
+	CameraID   string
+	Seq        uint64        // 采集器内递增的音频块序号，跳号即丢弃
+	CapturedAt time.Time     // 采集时的墙上时间
+	Mono       time.Duration // 采集时的进程内单调时钟，与 FrameMeta.Mono 同一基准
+	SampleRate int
+	Channels   int
+	Data       []byte
+}
+
+// framePool 帧对象及其缓冲区复用池
+var framePool = sync.Pool{
+	New: func() any {
+		return &Frame{}
+	},
+}
+
+// newFrame 从池中获取一帧并复制数据，初始引用计数为 1
+func newFrame(data []byte) *Frame {
+	f := framePool.Get().(*Frame)
+	if cap(f.data) < len(data) {
+		size := (len(data) + frameBufferAlign - 1) / frameBufferAlign * frameBufferAlign
+		f.data = make([]byte, size)
+	}
+	f.data = f.data[:len(data)]
+	copy(f.data, data)
+	f.meta = FrameMeta{}
+	f.pooled = true
+	f.refs.Store(1)
+	return f
+}
+
+// wrapFrame 包装已有的字节切片（不复制、不回收），初始引用计数为 1
+// 调用方之后不得再修改 data
+func wrapFrame(data []byte) *Frame {
+	f := &Frame{data: data}
+	f.refs.Store(1)
+	return f
+}
+
+// Bytes 获取帧数据（只读）
+func (f *Frame) Bytes() []byte {
+	return f.data
+}
+
+// Len 获取帧大小
+func (f *Frame) Len() int {
+	return len(f.data)
+}
+
+// Meta 获取帧元数据
+func (f *Frame) Meta() FrameMeta {
+	return f.meta
+}
+
+// Retain 增加一个引用
+func (f *Frame) Retain() *Frame {
+	if f.refs.Add(1) <= 1 {
+		panic("capture: Retain 已释放的帧")
+	}
+	return f
+}
+
+// Release 释放一个引用，归零时回收缓冲区
+func (f *Frame) Release() {
+	refs := f.refs.Add(-1)
+	if refs > 0 {
+		return
+	}
+	if refs < 0 {
+		panic("capture: 重复 Release 帧")
+	}
+	if !f.pooled {
+		return
+	}
+	if cap(f.data) > frameBufferMaxPool {
+		f.data = nil
+	}
+	f.data = f.data[:0]
+	framePool.Put(f)
+}
+
+// detach 为旧的 []byte 接口转出数据
+// 额外持有一个永不释放的引用，缓冲区不再回收，最终由 GC 回收
+func (f *Frame) detach() []byte {
+	f.Retain()
+	return f.data
+}
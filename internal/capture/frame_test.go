@@ -0,0 +1,358 @@
+package capture
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"home-monitor/internal/config"
+)
+
+// benchFrameSize 约等于 1080p MJPEG 的单帧大小
+const benchFrameSize = 200 * 1024
+
+// benchSubscriberCounts 典型场景：单路预览到多路预览 + HLS + RTMP + WebRTC
+var benchSubscriberCounts = []int{1, 4, 8}
+
+func benchJPEG() []byte {
+	data := make([]byte, benchFrameSize)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	data[0], data[1] = 0xFF, 0xD8
+	data[len(data)-2], data[len(data)-1] = 0xFF, 0xD9
+	return data
+}
+
+// BenchmarkFanOutCopy 改造前的分发方式：读取缓冲区复制一份，再为每个订阅者各复制一份
+func BenchmarkFanOutCopy(b *testing.B) {
+	src := benchJPEG()
+	for _, n := range benchSubscriberCounts {
+		b.Run(fmt.Sprintf("subscribers=%d", n), func(b *testing.B) {
+			subs := make([]chan []byte, n)
+			var wg sync.WaitGroup
+			for i := range subs {
+				subs[i] = make(chan []byte, 30)
+				wg.Add(1)
+				go func(ch chan []byte) {
+					defer wg.Done()
+					for range ch {
+					}
+				}(subs[i])
+			}
+
+			b.SetBytes(benchFrameSize)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				frame := make([]byte, len(src))
+				copy(frame, src)
+				for _, ch := range subs {
+					frameCopy := make([]byte, len(frame))
+					copy(frameCopy, frame)
+					ch <- frameCopy
+				}
+			}
+			b.StopTimer()
+
+			for _, ch := range subs {
+				close(ch)
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// BenchmarkFanOutShared 池化引用计数帧：每帧复制一次到池化缓冲区，所有订阅者共享
+func BenchmarkFanOutShared(b *testing.B) {
+	src := benchJPEG()
+	for _, n := range benchSubscriberCounts {
+		b.Run(fmt.Sprintf("subscribers=%d", n), func(b *testing.B) {
+			c := &baseCapturer{}
+			c.init(config.CameraConfig{ID: "bench"}, PrivacyAuto, nil)
+
+			var wg sync.WaitGroup
+			for i := 0; i < n; i++ {
+				ch := c.SubscribeFrameRefs(fmt.Sprintf("sub_%d", i))
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for frame := range ch {
+						frame.Release()
+					}
+				}()
+			}
+
+			b.SetBytes(benchFrameSize)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				frame := newFrame(src)
+				c.broadcastFrame(frame)
+				frame.Release()
+			}
+			b.StopTimer()
+
+			for i := 0; i < n; i++ {
+				c.UnsubscribeFrameRefs(fmt.Sprintf("sub_%d", i))
+			}
+			wg.Wait()
+		})
+	}
+}
+
+// filledFrame 创建内容全部为 b 的池化帧
+func filledFrame(size int, b byte) *Frame {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = b
+	}
+	return newFrame(data)
+}
+
+// checkFilled 检查帧内容未被覆盖
+func checkFilled(data []byte, size int, b byte) error {
+	if len(data) != size {
+		return fmt.Errorf("长度 = %d, want %d", len(data), size)
+	}
+	for i, v := range data {
+		if v != b {
+			return fmt.Errorf("data[%d] = %d, want %d", i, v, b)
+		}
+	}
+	return nil
+}
+
+func TestFrameRefCount(t *testing.T) {
+	f := filledFrame(1024, 7)
+	if got := f.refs.Load(); got != 1 {
+		t.Fatalf("初始 refs = %d, want 1", got)
+	}
+
+	if f.Retain() != f {
+		t.Fatal("Retain 应返回同一帧")
+	}
+	f.Retain()
+	if got := f.refs.Load(); got != 3 {
+		t.Fatalf("refs = %d, want 3", got)
+	}
+
+	f.Release()
+	f.Release()
+	if got := f.refs.Load(); got != 1 {
+		t.Fatalf("refs = %d, want 1", got)
+	}
+	if err := checkFilled(f.Bytes(), 1024, 7); err != nil {
+		t.Fatalf("仍有引用时数据被回收: %v", err)
+	}
+
+	f.Release()
+	if got := f.refs.Load(); got != 0 {
+		t.Fatalf("refs = %d, want 0", got)
+	}
+	if f.Len() != 0 {
+		t.Fatalf("引用归零后数据未清空: len = %d", f.Len())
+	}
+}
+
+func TestFrameReleaseMisuse(t *testing.T) {
+	mustPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s 未 panic", name)
+			}
+		}()
+		fn()
+	}
+
+	f := wrapFrame([]byte{0xFF, 0xD8})
+	f.Release()
+	mustPanic("重复 Release", f.Release)
+
+	g := wrapFrame([]byte{0xFF, 0xD8})
+	g.Release()
+	mustPanic("Retain 已释放的帧", func() { g.Retain() })
+}
+
+func TestFrameBufferReuse(t *testing.T) {
+	// sync.Pool 不保证一定返回放回的对象（-race 下会随机丢弃），多试几次
+	for attempt := 0; attempt < 100; attempt++ {
+		f := filledFrame(100*1024, 1)
+		capacity := cap(f.data)
+		f.Release()
+
+		g := filledFrame(10*1024, 2)
+		if g != f {
+			g.Release()
+			continue
+		}
+		if cap(g.data) != capacity {
+			t.Fatalf("复用的缓冲区容量 = %d, want %d", cap(g.data), capacity)
+		}
+		if err := checkFilled(g.Bytes(), 10*1024, 2); err != nil {
+			t.Fatalf("复用后数据错误: %v", err)
+		}
+		if got := g.refs.Load(); got != 1 {
+			t.Fatalf("复用后 refs = %d, want 1", got)
+		}
+		if g.Meta() != (FrameMeta{}) {
+			t.Fatalf("复用后元数据未清空: %+v", g.Meta())
+		}
+		g.Release()
+		return
+	}
+	t.Fatal("引用归零后缓冲区未被复用")
+}
+
+func TestFrameBufferAlign(t *testing.T) {
+	f := filledFrame(frameBufferAlign+1, 3)
+	defer f.Release()
+	if got := cap(f.data); got != 2*frameBufferAlign {
+		t.Fatalf("cap = %d, want %d", got, 2*frameBufferAlign)
+	}
+}
+
+func TestFrameLargeBufferNotPooled(t *testing.T) {
+	f := filledFrame(frameBufferMaxPool+1, 4)
+	f.Release()
+	if f.data != nil {
+		t.Fatalf("超过 frameBufferMaxPool 的缓冲区仍被持有: cap = %d", cap(f.data))
+	}
+
+	g := filledFrame(frameBufferMaxPool, 5)
+	capacity := cap(g.data)
+	g.Release()
+	if cap(g.data) != capacity {
+		t.Fatalf("不超过 frameBufferMaxPool 的缓冲区未保留: cap = %d, want %d", cap(g.data), capacity)
+	}
+}
+
+func TestFrameUnpooled(t *testing.T) {
+	data := []byte{0xFF, 0xD8, 0xFF, 0xD9}
+	f := wrapFrame(data)
+	f.Release()
+	if len(f.Bytes()) != len(data) {
+		t.Fatal("wrapFrame 包装的数据不应被回收")
+	}
+}
+
+func TestFrameDetach(t *testing.T) {
+	f := filledFrame(4096, 6)
+	data := f.detach()
+	f.Release()
+	if got := f.refs.Load(); got != 1 {
+		t.Fatalf("detach 后 refs = %d, want 1", got)
+	}
+
+	// 之后分配的帧不能占用 detach 出去的缓冲区
+	for i := 0; i < 10; i++ {
+		g := filledFrame(4096, 9)
+		if g == f {
+			t.Fatal("detach 的帧被放回了池中")
+		}
+		g.Release()
+	}
+	if err := checkFilled(data, 4096, 6); err != nil {
+		t.Fatalf("detach 的数据被覆盖: %v", err)
+	}
+}
+
+// TestFrameConcurrentRelease 多个订阅者并发读取和释放共享帧，需配合 -race 运行
+func TestFrameConcurrentRelease(t *testing.T) {
+	const (
+		frames    = 200
+		frameSize = 8 * 1024
+		refSubs   = 4
+	)
+	c := newTestCapturer(t)
+
+	// 只保留第一个错误，避免出错时阻塞订阅者
+	errs := make(chan error, 1)
+	report := func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < refSubs; i++ {
+		ch := c.SubscribeFrameRefs(fmt.Sprintf("ref_%d", i), WithPolicy(BoundedBlock), WithBufferSize(4))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range ch {
+				if err := checkFilled(f.Bytes(), frameSize, byte(f.Meta().Seq)); err != nil {
+					report(err)
+				}
+				f.Release()
+			}
+		}()
+	}
+
+	// 兼容接口的订阅者持有 detach 出来的切片，全部广播结束后再检查
+	byteCh := c.SubscribeFrames("bytes", WithPolicy(BoundedBlock), WithBufferSize(frames))
+	var detached [][]byte
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for data := range byteCh {
+			detached = append(detached, data)
+		}
+	}()
+
+	// 同时有读取最新帧的调用方
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if f, err := c.GetFrameRef(); err == nil {
+					if err := checkFilled(f.Bytes(), frameSize, byte(f.Meta().Seq)); err != nil {
+						report(err)
+					}
+					f.Release()
+				}
+			}
+		}()
+	}
+
+	c.mutex.Lock()
+	c.running = true
+	c.mutex.Unlock()
+
+	// 帧内容为序号，与广播时写入的 Seq 对应
+	for seq := 1; seq <= frames; seq++ {
+		f := filledFrame(frameSize, byte(seq))
+		c.broadcastFrame(f)
+		f.Release()
+	}
+
+	close(done)
+	readers.Wait()
+	for i := 0; i < refSubs; i++ {
+		c.UnsubscribeFrameRefs(fmt.Sprintf("ref_%d", i))
+	}
+	c.UnsubscribeFrames("bytes")
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		t.Fatalf("订阅者读到被回收的帧: %v", err)
+	}
+	if len(detached) != frames {
+		t.Fatalf("兼容接口收到 %d 帧, want %d", len(detached), frames)
+	}
+	for i, data := range detached {
+		if err := checkFilled(data, frameSize, byte(i+1)); err != nil {
+			t.Fatalf("第 %d 帧 detach 后被覆盖: %v", i+1, err)
+		}
+	}
+}
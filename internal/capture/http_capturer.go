@@ -0,0 +1,273 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"home-monitor/internal/config"
+)
+
+// HTTP 采集参数
+const (
+	httpMaxFrameSize      = 8 * 1024 * 1024 // 单帧最大字节数
+	httpRequestTimeout    = 10 * time.Second
+	httpMaxSnapshotErrors = 3 // 连续失败次数达到该值时交由 supervisor 重启
+)
+
+// HTTPCapturer 纯 Go 实现的 HTTP 采集器，不启动 FFmpeg 进程
+// 支持两种来源：
+// 1. mjpeg_http: multipart/x-mixed-replace MJPEG 流
+// 2. snapshot_http: 按帧率轮询单张 JPEG 快照
+// 仅提供预览帧，不含音频
+type HTTPCapturer struct {
+	baseCapturer
+
+	client *http.Client
+
+	// 认证状态：首次请求不带凭据，按服务器质询选择 Digest（优先）或 Basic
+	digest    *digestAuth
+	basicAuth bool
+	authMutex sync.Mutex
+
+	// 读取缓冲区，仅在 run 循环中使用，帧数据复制到池化缓冲区后复用
+	readBuf bytes.Buffer
+
+	// 隐私遮挡（未配置时为 nil）
+	privacy *privacyMasker
+}
+
+// newHTTPCapturer 创建 HTTP 采集器
+func newHTTPCapturer(cfg config.CameraConfig, opts captureOptions) *HTTPCapturer {
+	c := &HTTPCapturer{
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: httpRequestTimeout,
+			},
+		},
+		privacy: newPrivacyMasker(cfg.PrivacyMasks),
+	}
+	c.init(cfg, opts.privacyMode, c.run)
+	return c
+}
+
+// HasAudio HTTP 来源不含音频
+func (c *HTTPCapturer) HasAudio() bool {
+	return false
+}
+
+// run 运行一次 HTTP 采集，出错时返回由 supervisor 退避重启
+func (c *HTTPCapturer) run(ctx context.Context) error {
+	if c.config.HTTPUrl == "" {
+		return fmt.Errorf("未配置 http_url")
+	}
+	if c.config.Type == "snapshot_http" {
+		return c.runSnapshot(ctx)
+	}
+	return c.runStream(ctx)
+}
+
+// runStream 读取 multipart MJPEG 流，连接断开时返回
+func (c *HTTPCapturer) runStream(ctx context.Context) error {
+	resp, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return fmt.Errorf("不是 multipart MJPEG 流: %s", resp.Header.Get("Content-Type"))
+	}
+
+	reader := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("读取 MJPEG 流失败: %w", err)
+		}
+
+		frame, err := readJPEG(part, &c.readBuf)
+		part.Close()
+		if err != nil {
+			return err
+		}
+		if frame == nil {
+			continue
+		}
+		if frame, err = c.privacy.apply(frame); err != nil {
+			// 无法遮挡的帧直接丢弃
+			continue
+		}
+		c.broadcastFrame(frame)
+		frame.Release()
+	}
+}
+
+// runSnapshot 按帧率轮询快照地址
+func (c *HTTPCapturer) runSnapshot(ctx context.Context) error {
+	fps := c.config.FPS
+	if fps <= 0 {
+		fps = 1
+	}
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+
+	errCount := 0
+	for {
+		if err := c.fetchSnapshot(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			errCount++
+			if errCount >= httpMaxSnapshotErrors {
+				return err
+			}
+		} else {
+			errCount = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetchSnapshot 获取一张快照并广播
+func (c *HTTPCapturer) fetchSnapshot(ctx context.Context) error {
+	reqCtx, cancel := context.WithTimeout(ctx, httpRequestTimeout)
+	defer cancel()
+
+	resp, err := c.get(reqCtx)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	frame, err := readJPEG(resp.Body, &c.readBuf)
+	if err != nil {
+		return err
+	}
+	if frame == nil {
+		return fmt.Errorf("快照不是有效的 JPEG")
+	}
+	if frame, err = c.privacy.apply(frame); err != nil {
+		return err
+	}
+	c.broadcastFrame(frame)
+	frame.Release()
+	return nil
+}
+
+// get 发起带认证的 GET 请求
+// 凭据不会预先发送：收到 401 质询后按服务器提供的方式认证并重试一次，
+// 服务器提供 Digest 时使用 Digest，只提供 Basic 时才使用 Basic
+func (c *HTTPCapturer) get(ctx context.Context) (*http.Response, error) {
+	resp, err := c.doGet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.config.Username != "" {
+		challenges := resp.Header.Values("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := c.negotiateAuth(challenges); err != nil {
+			return nil, fmt.Errorf("认证失败: %w", err)
+		}
+		if resp, err = c.doGet(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP 请求失败: %s", resp.Status)
+	}
+	return resp, nil
+}
+
+// negotiateAuth 按 WWW-Authenticate 质询选择认证方式
+func (c *HTTPCapturer) negotiateAuth(challenges []string) error {
+	var digestChallenge string
+	var offersBasic bool
+	var schemes []string
+	for _, challenge := range challenges {
+		scheme, _, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+		schemes = append(schemes, scheme)
+		switch {
+		case strings.EqualFold(scheme, "Digest") && digestChallenge == "":
+			digestChallenge = challenge
+		case strings.EqualFold(scheme, "Basic"):
+			offersBasic = true
+		}
+	}
+
+	var digest *digestAuth
+	switch {
+	case digestChallenge != "":
+		var err error
+		if digest, err = newDigestAuth(digestChallenge, c.config.Username, c.config.Password); err != nil {
+			return err
+		}
+	case offersBasic:
+	default:
+		return fmt.Errorf("不支持的认证方式: %s", strings.Join(schemes, ", "))
+	}
+
+	c.authMutex.Lock()
+	c.digest = digest
+	c.basicAuth = digest == nil
+	c.authMutex.Unlock()
+	return nil
+}
+
+// doGet 发起一次 GET 请求，附带已协商的认证信息（尚未收到质询时不带凭据）
+func (c *HTTPCapturer) doGet(ctx context.Context) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.HTTPUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	c.authMutex.Lock()
+	digest, basicAuth := c.digest, c.basicAuth
+	c.authMutex.Unlock()
+
+	switch {
+	case digest != nil:
+		req.Header.Set("Authorization", digest.authorize(req.Method, req.URL.RequestURI()))
+	case basicAuth:
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	return c.client.Do(req)
+}
+
+// readJPEG 经 buf 读取一个 JPEG 帧，非 JPEG 内容返回 nil
+func readJPEG(r io.Reader, buf *bytes.Buffer) (*Frame, error) {
+	buf.Reset()
+	if _, err := buf.ReadFrom(io.LimitReader(r, httpMaxFrameSize+1)); err != nil {
+		return nil, fmt.Errorf("读取帧失败: %w", err)
+	}
+	data := buf.Bytes()
+	if len(data) > httpMaxFrameSize {
+		return nil, fmt.Errorf("帧超过 %d 字节", httpMaxFrameSize)
+	}
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xD8}) {
+		return nil, nil
+	}
+	return newFrame(data), nil
+}
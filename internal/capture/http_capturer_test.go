@@ -0,0 +1,91 @@
+package capture
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"home-monitor/internal/config"
+)
+
+// TestHTTPAuthNegotiation 凭据只在收到质询后发送，方式由服务器质询决定
+func TestHTTPAuthNegotiation(t *testing.T) {
+	tests := []struct {
+		name       string
+		challenges []string
+		wantScheme string // 重试请求的认证方式，空表示不重试
+	}{
+		{"仅 Digest", []string{`Digest realm="cam", nonce="abc", qop="auth"`}, "Digest"},
+		{"仅 Basic", []string{`Basic realm="cam"`}, "Basic"},
+		{"同时提供时使用 Digest", []string{`Basic realm="cam"`, `Digest realm="cam", nonce="abc"`}, "Digest"},
+		{"不支持的方式", []string{`Bearer realm="cam"`}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var auths []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				auths = append(auths, r.Header.Get("Authorization"))
+				mu.Unlock()
+				if r.Header.Get("Authorization") == "" {
+					for _, c := range tt.challenges {
+						w.Header().Add("WWW-Authenticate", c)
+					}
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte{0xFF, 0xD8, 0xFF, 0xD9})
+			}))
+			defer srv.Close()
+
+			c := newHTTPCapturer(config.CameraConfig{
+				ID:       "http",
+				Type:     "snapshot_http",
+				HTTPUrl:  srv.URL,
+				Username: "admin",
+				Password: "secret",
+			}, captureOptions{privacyMode: PrivacyAuto})
+
+			resp, err := c.get(context.Background())
+			if tt.wantScheme == "" {
+				if err == nil {
+					resp.Body.Close()
+					t.Fatal("不支持的认证方式应返回错误")
+				}
+				if len(auths) != 1 {
+					t.Fatalf("请求次数 = %d, want 1", len(auths))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if len(auths) != 2 {
+				t.Fatalf("请求次数 = %d, want 2", len(auths))
+			}
+			if auths[0] != "" {
+				t.Errorf("首次请求不应携带凭据: %q", auths[0])
+			}
+			if !strings.HasPrefix(auths[1], tt.wantScheme+" ") {
+				t.Errorf("重试请求认证 = %q, want %s", auths[1], tt.wantScheme)
+			}
+
+			// 之后的请求直接使用协商结果
+			resp, err = c.get(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if len(auths) != 3 || !strings.HasPrefix(auths[2], tt.wantScheme+" ") {
+				t.Errorf("后续请求认证 = %q, want %s", auths[len(auths)-1], tt.wantScheme)
+			}
+		})
+	}
+}
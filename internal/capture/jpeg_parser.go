@@ -0,0 +1,299 @@
+package capture
+
+import (
+	"bytes"
+	"sync/atomic"
+)
+
+// jpegMaxFrameSize 单帧最大字节数，超过时丢弃该帧并重新同步
+const jpegMaxFrameSize = 8 * 1024 * 1024
+
+// JPEG 标记
+const (
+	jpegMarkerSOI  = 0xD8
+	jpegMarkerEOI  = 0xD9
+	jpegMarkerSOS  = 0xDA
+	jpegMarkerTEM  = 0x01
+	jpegMarkerRST0 = 0xD0
+	jpegMarkerRST7 = 0xD7
+)
+
+// 解析状态
+const (
+	jpegStateSOI     = iota // 查找帧起始 SOI
+	jpegStateSegment        // 逐段跳过 APPn/DQT/DHT/SOF 等带长度的段
+	jpegStateScan           // 扫描熵编码数据，查找 EOI 或下一个标记
+)
+
+// ParserStats JPEG 流解析计数
+type ParserStats struct {
+	Frames       uint64 `json:"frames"`        // 完整帧数
+	Malformed    uint64 `json:"malformed"`     // 结构错误或被截断而丢弃的帧
+	Oversized    uint64 `json:"oversized"`     // 超过大小上限而丢弃的帧
+	SkippedBytes uint64 `json:"skipped_bytes"` // 帧之间被跳过的无效字节
+}
+
+// parserCounters 解析计数器，跨管线重启累计
+type parserCounters struct {
+	frames       atomic.Uint64
+	malformed    atomic.Uint64
+	oversized    atomic.Uint64
+	skippedBytes atomic.Uint64
+}
+
+// snapshot 获取计数快照
+func (c *parserCounters) snapshot() ParserStats {
+	return ParserStats{
+		Frames:       c.frames.Load(),
+		Malformed:    c.malformed.Load(),
+		Oversized:    c.oversized.Load(),
+		SkippedBytes: c.skippedBytes.Load(),
+	}
+}
+
+// jpegParser 按标记结构切分 MJPEG 字节流的流式解析器
+// 按段长度跳过 APPn 等段（其中内嵌的 EXIF 缩略图不会被误判为帧结束），
+// 只在熵编码数据中查找 EOI；数据不足时记住解析位置，下次写入从该位置继续，不重复扫描。
+type jpegParser struct {
+	buf     []byte
+	start   int // 当前帧 SOI 的偏移
+	pos     int // 下一个待解析字节的偏移
+	state   int
+	maxSize int
+
+	counters *parserCounters
+}
+
+// newJPEGParser 创建解析器，counters 可与其他解析器共享
+func newJPEGParser(maxSize int, counters *parserCounters) *jpegParser {
+	if counters == nil {
+		counters = &parserCounters{}
+	}
+	return &jpegParser{
+		maxSize:  maxSize,
+		counters: counters,
+	}
+}
+
+// Write 写入一段流数据，每解析出一个完整帧调用一次 emit
+// 传给 emit 的切片仅在回调期间有效
+func (p *jpegParser) Write(data []byte, emit func(frame []byte)) {
+	p.buf = append(p.buf, data...)
+
+	for p.parse(emit) {
+	}
+
+	p.compact()
+}
+
+// parse 推进一步，返回 false 表示需要更多数据
+func (p *jpegParser) parse(emit func(frame []byte)) bool {
+	switch p.state {
+	case jpegStateSOI:
+		return p.findSOI()
+	case jpegStateSegment:
+		return p.parseSegment()
+	default:
+		return p.parseScan(emit)
+	}
+}
+
+// findSOI 查找下一个 SOI
+func (p *jpegParser) findSOI() bool {
+	idx := bytes.Index(p.buf[p.pos:], []byte{0xFF, jpegMarkerSOI})
+	if idx < 0 {
+		// 末尾的 0xFF 可能是下一个 SOI 的前半部分
+		end := len(p.buf)
+		if end > p.pos && p.buf[end-1] == 0xFF {
+			end--
+		}
+		p.counters.skippedBytes.Add(uint64(end - p.pos))
+		p.pos = end
+		p.start = end
+		return false
+	}
+
+	p.counters.skippedBytes.Add(uint64(idx))
+	p.start = p.pos + idx
+	p.pos = p.start + 2
+	p.state = jpegStateSegment
+	return true
+}
+
+// parseSegment 解析 pos 处的一个标记段
+func (p *jpegParser) parseSegment() bool {
+	if p.oversized() {
+		return true
+	}
+	if len(p.buf)-p.pos < 2 {
+		return false
+	}
+	if p.buf[p.pos] != 0xFF {
+		p.malformed(p.pos)
+		return true
+	}
+
+	marker := p.buf[p.pos+1]
+	switch {
+	case marker == 0xFF:
+		// 填充字节
+		p.pos++
+		return true
+	case marker == jpegMarkerSOI:
+		// 上一帧被截断，从新的 SOI 开始
+		p.malformed(p.pos)
+		return true
+	case marker == jpegMarkerEOI, marker == 0x00:
+		// 没有图像数据的帧或非法标记
+		p.malformed(p.pos + 2)
+		return true
+	case marker == jpegMarkerTEM, marker >= jpegMarkerRST0 && marker <= jpegMarkerRST7:
+		// 无长度的独立标记
+		p.pos += 2
+		return true
+	}
+
+	if len(p.buf)-p.pos < 4 {
+		return false
+	}
+	length := int(p.buf[p.pos+2])<<8 | int(p.buf[p.pos+3])
+	if length < 2 {
+		p.malformed(p.pos + 2)
+		return true
+	}
+	if p.pos+2+length-p.start > p.maxSize {
+		p.dropOversized()
+		return true
+	}
+	if len(p.buf)-p.pos < 2+length {
+		return false
+	}
+
+	p.pos += 2 + length
+	if marker == jpegMarkerSOS {
+		p.state = jpegStateScan
+	}
+	return true
+}
+
+// parseScan 在熵编码数据中查找下一个标记
+func (p *jpegParser) parseScan(emit func(frame []byte)) bool {
+	for {
+		if p.oversized() {
+			return true
+		}
+
+		idx := bytes.IndexByte(p.buf[p.pos:], 0xFF)
+		if idx < 0 {
+			p.pos = len(p.buf)
+			return false
+		}
+		p.pos += idx
+		if len(p.buf)-p.pos < 2 {
+			return false
+		}
+
+		marker := p.buf[p.pos+1]
+		switch {
+		case marker == 0x00, marker >= jpegMarkerRST0 && marker <= jpegMarkerRST7:
+			// 字节填充或重启标记，仍属于熵编码数据
+			p.pos += 2
+		case marker == 0xFF:
+			p.pos++
+		case marker == jpegMarkerEOI:
+			end := p.pos + 2
+			if end-p.start > p.maxSize {
+				p.pos = end
+				p.dropOversized()
+				return true
+			}
+			p.counters.frames.Add(1)
+			emit(p.buf[p.start:end])
+			p.start = end
+			p.pos = end
+			p.state = jpegStateSOI
+			return true
+		case marker == jpegMarkerSOI:
+			// 扫描数据中出现新的 SOI，上一帧被截断
+			p.malformed(p.pos)
+			return true
+		default:
+			// 渐进式 JPEG 的下一个 DHT/SOS 等段
+			p.state = jpegStateSegment
+			return true
+		}
+	}
+}
+
+// oversized 当前帧超过大小上限时丢弃
+func (p *jpegParser) oversized() bool {
+	if p.pos-p.start <= p.maxSize {
+		return false
+	}
+	p.dropOversized()
+	return true
+}
+
+// dropOversized 丢弃当前帧并从当前位置重新查找 SOI
+func (p *jpegParser) dropOversized() {
+	p.counters.oversized.Add(1)
+	p.resync(p.pos)
+}
+
+// malformed 丢弃当前帧并从 next 处重新查找 SOI
+func (p *jpegParser) malformed(next int) {
+	p.counters.malformed.Add(1)
+	p.resync(next)
+}
+
+// resync 放弃当前帧，从 next 处重新查找 SOI
+func (p *jpegParser) resync(next int) {
+	if next > len(p.buf) {
+		next = len(p.buf)
+	}
+	p.start = next
+	p.pos = next
+	p.state = jpegStateSOI
+}
+
+// compact 丢弃已消费的数据，保留当前帧
+func (p *jpegParser) compact() {
+	if p.start == 0 {
+		return
+	}
+	n := copy(p.buf, p.buf[p.start:])
+	p.buf = p.buf[:n]
+	p.pos -= p.start
+	p.start = 0
+}
+
+// jpegDimensions 从 SOF 段读取 JPEG 宽高，只遍历帧头部的段，无法解析时返回 0
+func jpegDimensions(data []byte) (width, height int) {
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 0, 0
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		if marker == jpegMarkerSOS || marker == jpegMarkerEOI {
+			return 0, 0
+		}
+
+		length := int(data[pos+2])<<8 | int(data[pos+3])
+		// SOF0-SOF15，排除 DHT(C4)、JPG(C8)、DAC(CC)
+		if marker >= 0xC0 && marker <= 0xCF && marker != 0xC4 && marker != 0xC8 && marker != 0xCC {
+			if length < 7 || pos+9 > len(data) {
+				return 0, 0
+			}
+			height = int(data[pos+5])<<8 | int(data[pos+6])
+			width = int(data[pos+7])<<8 | int(data[pos+8])
+			return width, height
+		}
+		pos += 2 + length
+	}
+	return 0, 0
+}
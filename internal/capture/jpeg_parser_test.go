@@ -0,0 +1,142 @@
+package capture
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// encodeTestJPEG 生成一张 w×h 的 JPEG
+func encodeTestJPEG(t testing.TB, w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 7), uint8(y * 13), uint8(x ^ y), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withEXIFThumbnail 在 SOI 之后插入内嵌缩略图的 APP1 段（模拟 EXIF 缩略图）
+func withEXIFThumbnail(t testing.TB, frame, thumb []byte) []byte {
+	payload := append([]byte("Exif\x00\x00"), thumb...)
+	length := len(payload) + 2
+	if length > 0xFFFF {
+		t.Fatal("缩略图过大")
+	}
+
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1, byte(length >> 8), byte(length)}
+	out = append(out, payload...)
+	return append(out, frame[2:]...)
+}
+
+// parseAll 按 chunk 大小分段写入，返回解析出的帧
+func parseAll(data []byte, chunk, maxSize int) ([][]byte, ParserStats) {
+	parser := newJPEGParser(maxSize, nil)
+	var frames [][]byte
+	emit := func(frame []byte) {
+		frames = append(frames, append([]byte(nil), frame...))
+	}
+	for len(data) > 0 {
+		n := min(chunk, len(data))
+		parser.Write(data[:n], emit)
+		data = data[n:]
+	}
+	return frames, parser.counters.snapshot()
+}
+
+func TestJPEGParserEmbeddedThumbnail(t *testing.T) {
+	thumb := encodeTestJPEG(t, 16, 16)
+	frame := withEXIFThumbnail(t, encodeTestJPEG(t, 64, 48), thumb)
+	plain := encodeTestJPEG(t, 32, 32)
+
+	var stream []byte
+	stream = append(stream, frame...)
+	stream = append(stream, plain...)
+	stream = append(stream, frame...)
+
+	for _, chunk := range []int{1, 7, 4096, len(stream)} {
+		frames, stats := parseAll(stream, chunk, jpegMaxFrameSize)
+		if len(frames) != 3 {
+			t.Fatalf("chunk=%d: 解析出 %d 帧，期望 3", chunk, len(frames))
+		}
+		if !bytes.Equal(frames[0], frame) || !bytes.Equal(frames[1], plain) || !bytes.Equal(frames[2], frame) {
+			t.Fatalf("chunk=%d: 帧内容不一致", chunk)
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(frames[0])); err != nil {
+			t.Fatalf("chunk=%d: 解析出的帧无法解码: %v", chunk, err)
+		}
+		if stats.Malformed != 0 || stats.Oversized != 0 {
+			t.Fatalf("chunk=%d: 意外的错误计数 %+v", chunk, stats)
+		}
+	}
+}
+
+func TestJPEGParserResync(t *testing.T) {
+	frame := encodeTestJPEG(t, 32, 32)
+
+	var stream []byte
+	stream = append(stream, "garbage"...)
+	stream = append(stream, frame[:len(frame)-40]...) // 熵编码数据中被截断的帧
+	stream = append(stream, frame...)
+	stream = append(stream, frame...)
+
+	frames, stats := parseAll(stream, 512, jpegMaxFrameSize)
+	if len(frames) != 2 {
+		t.Fatalf("解析出 %d 帧，期望 2", len(frames))
+	}
+	if stats.Malformed != 1 {
+		t.Fatalf("malformed = %d，期望 1", stats.Malformed)
+	}
+	if stats.SkippedBytes != uint64(len("garbage")) {
+		t.Fatalf("skipped_bytes = %d，期望 %d", stats.SkippedBytes, len("garbage"))
+	}
+
+	frames, stats = parseAll(append(frame, frame...), 512, len(frame)-1)
+	if len(frames) != 0 || stats.Oversized == 0 {
+		t.Fatalf("超限帧未被丢弃: frames=%d stats=%+v", len(frames), stats)
+	}
+}
+
+// FuzzJPEGParser 任意输入不应 panic；输出帧结构完整、不超限，且与分段方式无关
+func FuzzJPEGParser(f *testing.F) {
+	frame := encodeTestJPEG(f, 16, 16)
+	f.Add(frame, 1)
+	f.Add(append(frame, frame...), 3)
+	f.Add(withEXIFThumbnail(f, frame, encodeTestJPEG(f, 8, 8)), 64)
+	f.Add([]byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02, 0xFF, 0x00, 0xFF, 0xD0, 0xFF, 0xD9}, 2)
+	f.Add([]byte{0xFF, 0xD8, 0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x01, 0xFF}, 1)
+
+	const maxSize = 64 * 1024
+
+	f.Fuzz(func(t *testing.T, data []byte, chunk int) {
+		if chunk <= 0 {
+			chunk = 1
+		}
+
+		whole, _ := parseAll(data, len(data)+1, maxSize)
+		split, _ := parseAll(data, chunk, maxSize)
+
+		if len(whole) != len(split) {
+			t.Fatalf("分段写入解析出 %d 帧，整体写入 %d 帧", len(split), len(whole))
+		}
+		for i := range whole {
+			if !bytes.Equal(whole[i], split[i]) {
+				t.Fatalf("第 %d 帧与分段方式有关", i)
+			}
+			frame := whole[i]
+			if len(frame) < 4 || len(frame) > maxSize {
+				t.Fatalf("第 %d 帧大小异常: %d", i, len(frame))
+			}
+			if !bytes.HasPrefix(frame, []byte{0xFF, 0xD8}) || !bytes.HasSuffix(frame, []byte{0xFF, 0xD9}) {
+				t.Fatalf("第 %d 帧缺少 SOI/EOI", i)
+			}
+		}
+	})
+}
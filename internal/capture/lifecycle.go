@@ -0,0 +1,187 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"home-monitor/internal/config"
+	"home-monitor/internal/ffmpeglog"
+)
+
+// EventType 采集器生命周期事件类型
+type EventType string
+
+const (
+	EventStarted  EventType = "started"  // 采集器已启动
+	EventStopping EventType = "stopping" // 采集器即将停止
+	EventRemoved  EventType = "removed"  // 采集器已移除，依赖方应清理该摄像头的状态
+)
+
+// Event 采集器生命周期事件
+type Event struct {
+	Type     EventType
+	CameraID string
+	// 是否为重启过程中的事件（停止后会立即重新启动）
+	Restarting bool
+	// 重启是否由配置变更引起（分辨率、帧率等可能已改变，依赖方需按新配置重建）
+	ConfigChanged bool
+}
+
+// Listener 生命周期事件监听函数
+// 同步调用：收到 EventStopping 时应在返回前释放对采集器的订阅，
+// 收到 EventStarted 时可重新订阅，收到 EventRemoved 时清理该摄像头的缓存数据
+type Listener func(Event)
+
+// AddListener 注册生命周期事件监听
+func (m *Manager) AddListener(l Listener) {
+	m.listenerMutex.Lock()
+	defer m.listenerMutex.Unlock()
+	m.listeners = append(m.listeners, l)
+}
+
+// emit 按注册顺序通知监听者
+func (m *Manager) emit(event Event) {
+	m.listenerMutex.RLock()
+	listeners := make([]Listener, len(m.listeners))
+	copy(listeners, m.listeners)
+	m.listenerMutex.RUnlock()
+
+	for _, l := range listeners {
+		l(event)
+	}
+}
+
+// baseContext 获取启动采集器使用的长期 context
+func (m *Manager) baseContext() context.Context {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if m.ctx != nil {
+		return m.ctx
+	}
+	return context.Background()
+}
+
+// StartCapturer 启动单个采集器并通知依赖方重新挂接
+func (m *Manager) StartCapturer(id string) error {
+	m.lifecycleMutex.Lock()
+	defer m.lifecycleMutex.Unlock()
+
+	capturer, err := m.GetCapturer(id)
+	if err != nil {
+		return err
+	}
+	if capturer.IsRunning() {
+		return fmt.Errorf("采集器 %s 已在运行", id)
+	}
+
+	if err := capturer.Start(m.baseContext()); err != nil {
+		return err
+	}
+	m.emit(Event{Type: EventStarted, CameraID: id})
+	return nil
+}
+
+// StopCapturer 通知依赖方拆除后停止单个采集器
+func (m *Manager) StopCapturer(id string) error {
+	m.lifecycleMutex.Lock()
+	defer m.lifecycleMutex.Unlock()
+
+	capturer, err := m.GetCapturer(id)
+	if err != nil {
+		return err
+	}
+	if !capturer.IsRunning() {
+		return fmt.Errorf("采集器 %s 未运行", id)
+	}
+
+	m.emit(Event{Type: EventStopping, CameraID: id})
+	return capturer.Stop()
+}
+
+// RestartCapturer 重启单个采集器
+// 依赖方收到 Restarting 标记的事件，可在重启后恢复原有输出
+func (m *Manager) RestartCapturer(id string) error {
+	m.lifecycleMutex.Lock()
+	defer m.lifecycleMutex.Unlock()
+
+	capturer, err := m.GetCapturer(id)
+	if err != nil {
+		return err
+	}
+
+	if capturer.IsRunning() {
+		m.emit(Event{Type: EventStopping, CameraID: id, Restarting: true})
+		if err := capturer.Stop(); err != nil {
+			return err
+		}
+	}
+
+	if err := capturer.Start(m.baseContext()); err != nil {
+		return err
+	}
+	m.emit(Event{Type: EventStarted, CameraID: id, Restarting: true})
+
+	log.Printf("采集器 %s 已重启", id)
+	return nil
+}
+
+// ReplaceCapturer 按新配置替换采集器
+// 原采集器运行中时先停止，新采集器启动后依赖方按新配置恢复输出
+func (m *Manager) ReplaceCapturer(cfg config.CameraConfig) (AVCapturer, error) {
+	m.lifecycleMutex.Lock()
+	defer m.lifecycleMutex.Unlock()
+
+	old, err := m.GetCapturer(cfg.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	wasRunning := old.IsRunning()
+	if wasRunning {
+		m.emit(Event{Type: EventStopping, CameraID: cfg.ID, Restarting: true, ConfigChanged: true})
+		old.Stop()
+	}
+
+	m.mutex.Lock()
+	capturer := newCapturer(cfg, m.optionsFor(cfg.ID))
+	m.capturers[cfg.ID] = capturer
+	m.mutex.Unlock()
+
+	if wasRunning {
+		if err := capturer.Start(m.baseContext()); err != nil {
+			return nil, err
+		}
+		m.emit(Event{Type: EventStarted, CameraID: cfg.ID, Restarting: true, ConfigChanged: true})
+	}
+
+	log.Printf("已更新采集器: %s (%s)", cfg.Name, cfg.ID)
+	return capturer, nil
+}
+
+// RemoveCapturer 停止并移除采集器
+func (m *Manager) RemoveCapturer(id string) error {
+	m.lifecycleMutex.Lock()
+	defer m.lifecycleMutex.Unlock()
+
+	capturer, err := m.GetCapturer(id)
+	if err != nil {
+		return err
+	}
+
+	if capturer.IsRunning() {
+		m.emit(Event{Type: EventStopping, CameraID: id})
+		capturer.Stop()
+	}
+
+	m.mutex.Lock()
+	delete(m.capturers, id)
+	m.mutex.Unlock()
+
+	// 同 ID 重新创建的摄像头不显示旧日志
+	ffmpeglog.Remove(id)
+	m.emit(Event{Type: EventRemoved, CameraID: id})
+
+	log.Printf("已移除采集器: %s", id)
+	return nil
+}
@@ -0,0 +1,133 @@
+package capture
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"home-monitor/internal/config"
+)
+
+// OSD 文字与画面边缘的距离（像素）
+const osdMargin = 10
+
+// osdTextSetter 支持 OSD 动态文本的采集器
+type osdTextSetter interface {
+	setOSDText(text string) error
+}
+
+// osdOverlay 画面叠加文字
+// drawtext 从文本文件读取内容并每帧重读（reload=1），动态文本更新时原子替换文件，无需重启 FFmpeg
+type osdOverlay struct {
+	cfg  config.OSDConfig
+	name string // 摄像头名称
+	path string // 文本文件路径
+
+	// API 设置的动态文本，显示在最后一行
+	dynamic string
+	mutex   sync.Mutex
+}
+
+// newOSDOverlay 创建 OSD，未启用时返回 nil
+func newOSDOverlay(cfg config.CameraConfig, dynamic string) *osdOverlay {
+	if !cfg.OSD.Enabled {
+		return nil
+	}
+	return &osdOverlay{
+		cfg:     cfg.OSD,
+		name:    cfg.Name,
+		path:    osdTextPath(cfg.ID),
+		dynamic: dynamic,
+	}
+}
+
+// osdTextPath OSD 文本文件路径
+func osdTextPath(cameraID string) string {
+	return filepath.Join(os.TempDir(), "home-monitor", "osd_"+cameraID+".txt")
+}
+
+// filter 生成 drawtext 滤镜
+func (o *osdOverlay) filter() string {
+	x, y := fmt.Sprintf("%d", osdMargin), fmt.Sprintf("%d", osdMargin)
+	switch o.cfg.Position {
+	case config.OSDTopRight:
+		x = fmt.Sprintf("w-tw-%d", osdMargin)
+	case config.OSDBottomLeft:
+		y = fmt.Sprintf("h-th-%d", osdMargin)
+	case config.OSDBottomRight:
+		x = fmt.Sprintf("w-tw-%d", osdMargin)
+		y = fmt.Sprintf("h-th-%d", osdMargin)
+	}
+
+	var opts []string
+	if o.cfg.FontFile != "" {
+		opts = append(opts, "fontfile='"+o.cfg.FontFile+"'")
+	}
+	opts = append(opts,
+		"textfile='"+o.path+"'",
+		"reload=1",
+		fmt.Sprintf("fontsize=%d", o.cfg.FontSize),
+		"fontcolor="+o.cfg.FontColor,
+		"box=1",
+		"boxcolor=black@0.4",
+		"boxborderw=5",
+		"line_spacing=4",
+		"x="+x,
+		"y="+y,
+	)
+	return "drawtext=" + strings.Join(opts, ":")
+}
+
+// text 生成文本文件内容：第一行为名称和时间，其后为固定文本和动态文本
+func (o *osdOverlay) text() string {
+	line := "%{localtime:" + escapeOSDArg(o.cfg.TimeFormat) + "}"
+	if o.cfg.ShowName {
+		line = escapeOSDText(o.name) + "  " + line
+	}
+	lines := []string{line}
+	if o.cfg.Text != "" {
+		lines = append(lines, escapeOSDText(o.cfg.Text))
+	}
+	if o.dynamic != "" {
+		lines = append(lines, escapeOSDText(o.dynamic))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// write 原子写入文本文件，避免 FFmpeg 读到写了一半的内容
+func (o *osdOverlay) write() error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(o.path), 0755); err != nil {
+		return fmt.Errorf("创建 OSD 目录失败: %w", err)
+	}
+	tmp := o.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(o.text()), 0644); err != nil {
+		return fmt.Errorf("写入 OSD 文本失败: %w", err)
+	}
+	if err := os.Rename(tmp, o.path); err != nil {
+		return fmt.Errorf("写入 OSD 文本失败: %w", err)
+	}
+	return nil
+}
+
+// setText 更新动态文本
+func (o *osdOverlay) setText(text string) error {
+	o.mutex.Lock()
+	o.dynamic = text
+	o.mutex.Unlock()
+	return o.write()
+}
+
+// escapeOSDText 转义 drawtext 文本扩展中的反斜杠和 %
+func escapeOSDText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`).Replace(s)
+}
+
+// escapeOSDArg 转义 %{...} 函数参数中的分隔符
+func escapeOSDArg(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `:`, `\:`, `}`, `\}`).Replace(s)
+}
@@ -0,0 +1,179 @@
+package capture
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"home-monitor/internal/config"
+)
+
+const (
+	// FFmpeg 遮挡图的渲染尺寸，叠加前按源分辨率缩放
+	maskImageWidth  = 1920
+	maskImageHeight = 1080
+	// Go 中重新编码已遮挡帧的 JPEG 质量
+	maskJPEGQuality = 85
+)
+
+// previewMaskColor 遮挡预览中区域的填充色（半透明红色）
+var previewMaskColor = color.NRGBA{R: 0xFF, A: 0x80}
+
+// rasterizeMasks 将归一化的遮挡多边形渲染为 width x height 的覆盖图，被遮挡像素为 0xFF
+// 按像素中心做扫描线填充（奇偶规则）
+func rasterizeMasks(masks []config.PrivacyMask, width, height int) *image.Alpha {
+	cover := image.NewAlpha(image.Rect(0, 0, width, height))
+	var xs []float64
+	for _, m := range masks {
+		n := len(m.Points)
+		for y := 0; y < height; y++ {
+			cy := (float64(y) + 0.5) / float64(height)
+			xs = xs[:0]
+			for i := 0; i < n; i++ {
+				a, b := m.Points[i], m.Points[(i+1)%n]
+				if (a[1] <= cy) == (b[1] <= cy) {
+					continue
+				}
+				xs = append(xs, a[0]+(cy-a[1])/(b[1]-a[1])*(b[0]-a[0]))
+			}
+			slices.Sort(xs)
+			row := cover.Pix[y*cover.Stride : y*cover.Stride+width]
+			for i := 0; i+1 < len(xs); i += 2 {
+				x0 := min(max(int(math.Ceil(xs[i]*float64(width)-0.5)), 0), width)
+				x1 := min(max(int(math.Ceil(xs[i+1]*float64(width)-0.5)), 0), width)
+				for x := x0; x < x1; x++ {
+					row[x] = 0xFF
+				}
+			}
+		}
+	}
+	return cover
+}
+
+// privacyMaskPath FFmpeg 遮挡图文件路径
+func privacyMaskPath(cameraID string) string {
+	return filepath.Join(os.TempDir(), "home-monitor", "privacy_"+cameraID+".png")
+}
+
+// writePrivacyMaskImage 写入 FFmpeg 叠加用的遮挡图：遮挡区域为不透明黑色，其余透明
+func writePrivacyMaskImage(masks []config.PrivacyMask, path string) error {
+	cover := rasterizeMasks(masks, maskImageWidth, maskImageHeight)
+	img := image.NewNRGBA(cover.Rect)
+	draw.DrawMask(img, img.Rect, image.Black, image.Point{}, cover, image.Point{}, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return fmt.Errorf("编码遮挡图失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建遮挡图目录失败: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("写入遮挡图失败: %w", err)
+	}
+	return nil
+}
+
+// privacyMasker 在 Go 中为 JPEG 帧涂黑遮挡区域，用于不经过 FFmpeg 滤镜的 HTTP、推送型采集器
+type privacyMasker struct {
+	masks []config.PrivacyMask
+
+	// 按最近一帧尺寸渲染的覆盖图，尺寸变化时重新渲染
+	cover *image.Alpha
+	mutex sync.Mutex
+}
+
+// newPrivacyMasker 创建遮挡器，未配置遮挡区域时返回 nil
+func newPrivacyMasker(masks []config.PrivacyMask) *privacyMasker {
+	if len(masks) == 0 {
+		return nil
+	}
+	return &privacyMasker{masks: masks}
+}
+
+// coverFor 获取指定尺寸的覆盖图
+func (m *privacyMasker) coverFor(bounds image.Rectangle) *image.Alpha {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.cover == nil || m.cover.Rect.Size() != bounds.Size() {
+		m.cover = rasterizeMasks(m.masks, bounds.Dx(), bounds.Dy())
+	}
+	return m.cover
+}
+
+// apply 返回已遮挡的新帧并释放原帧，m 为 nil（未配置遮挡）时原样返回
+// 解码失败时同样释放原帧并返回错误，调用方丢弃该帧，避免未遮挡的画面流出
+func (m *privacyMasker) apply(frame *Frame) (*Frame, error) {
+	if m == nil {
+		return frame, nil
+	}
+	defer frame.Release()
+
+	img, err := jpeg.Decode(bytes.NewReader(frame.Bytes()))
+	if err != nil {
+		return nil, fmt.Errorf("解码帧失败: %w", err)
+	}
+	img = paintMasked(img, m.coverFor(img.Bounds()))
+
+	var buf bytes.Buffer
+	buf.Grow(frame.Len())
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: maskJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("编码帧失败: %w", err)
+	}
+	return newFrame(buf.Bytes()), nil
+}
+
+// paintMasked 将覆盖图中的像素涂黑
+// JPEG 解码得到的 YCbCr 图像原地修改亮度和色度，其他格式转换为 RGBA 后绘制
+func paintMasked(img image.Image, cover *image.Alpha) image.Image {
+	bounds := img.Bounds()
+	if ycc, ok := img.(*image.YCbCr); ok {
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			row := cover.Pix[(y-bounds.Min.Y)*cover.Stride:]
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				if row[x-bounds.Min.X] == 0 {
+					continue
+				}
+				ycc.Y[ycc.YOffset(x, y)] = 0
+				c := ycc.COffset(x, y)
+				ycc.Cb[c] = 0x80
+				ycc.Cr[c] = 0x80
+			}
+		}
+		return ycc
+	}
+
+	rgba := image.NewRGBA(bounds)
+	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+	draw.DrawMask(rgba, bounds, image.Black, image.Point{}, cover, image.Point{}, draw.Over)
+	return rgba
+}
+
+// PreviewPrivacyMasks 在 JPEG 快照上以半透明红色标出遮挡区域，用于配置时预览
+func PreviewPrivacyMasks(snapshot []byte, masks []config.PrivacyMask) ([]byte, error) {
+	img, err := jpeg.Decode(bytes.NewReader(snapshot))
+	if err != nil {
+		return nil, fmt.Errorf("解码快照失败: %w", err)
+	}
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Rect, img, bounds.Min, draw.Src)
+
+	cover := rasterizeMasks(masks, bounds.Dx(), bounds.Dy())
+	draw.DrawMask(rgba, rgba.Rect, image.NewUniform(previewMaskColor), image.Point{}, cover, image.Point{}, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, rgba, &jpeg.Options{Quality: maskJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("编码预览失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
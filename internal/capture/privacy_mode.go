@@ -0,0 +1,263 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"log"
+	"time"
+
+	"home-monitor/internal/config"
+)
+
+// PrivacyMode 隐私模式的手动设置
+type PrivacyMode string
+
+const (
+	PrivacyAuto PrivacyMode = "auto" // 按计划时段（默认）
+	PrivacyOn   PrivacyMode = "on"   // 手动开启，直到改回 auto 或 off
+	PrivacyOff  PrivacyMode = "off"  // 手动关闭，忽略计划时段
+)
+
+// ParsePrivacyMode 解析隐私模式设置
+func ParsePrivacyMode(s string) (PrivacyMode, bool) {
+	switch PrivacyMode(s) {
+	case PrivacyAuto, PrivacyOn, PrivacyOff:
+		return PrivacyMode(s), true
+	}
+	return "", false
+}
+
+// PrivacyStatus 隐私模式状态
+type PrivacyStatus struct {
+	Active    bool        `json:"active"`    // 当前是否处于隐私模式
+	Mode      PrivacyMode `json:"mode"`      // 手动设置
+	Scheduled bool        `json:"scheduled"` // 当前是否处于计划时段
+	Since     time.Time   `json:"since"`     // 最近一次进入或退出的时间，未切换过时为零值
+}
+
+const (
+	// 计划时段检查间隔
+	privacyCheckInterval = 15 * time.Second
+	// 占位画面默认尺寸（来源尺寸未知时）
+	placeholderWidth  = 640
+	placeholderHeight = 360
+	// 占位画面期间的静音音频块时长
+	placeholderAudioInterval = 20 * time.Millisecond
+)
+
+// setPrivacyMode 更新手动设置，需要时切换管线
+func (c *baseCapturer) setPrivacyMode(mode PrivacyMode) {
+	c.privacyMu.Lock()
+	changed := c.privacyMode != mode
+	c.privacyMode = mode
+	c.privacyMu.Unlock()
+
+	if changed {
+		log.Printf("摄像头 %s 隐私模式设置为 %s", c.config.ID, mode)
+	}
+	c.updatePrivacy()
+}
+
+// SetPrivacyMode 手动设置隐私模式
+func (c *baseCapturer) SetPrivacyMode(mode PrivacyMode) {
+	c.setPrivacyMode(mode)
+}
+
+// PrivacyStatus 获取隐私模式状态
+func (c *baseCapturer) PrivacyStatus() PrivacyStatus {
+	c.privacyMu.Lock()
+	defer c.privacyMu.Unlock()
+	return PrivacyStatus{
+		Active:    c.privacyActive,
+		Mode:      c.privacyMode,
+		Scheduled: config.PrivacyScheduled(c.config.PrivacySchedules, time.Now()),
+		Since:     c.privacySince,
+	}
+}
+
+// inPrivacy 当前是否处于隐私模式
+func (c *baseCapturer) inPrivacy() bool {
+	c.privacyMu.Lock()
+	defer c.privacyMu.Unlock()
+	return c.privacyActive
+}
+
+// updatePrivacy 按手动设置和计划时段计算隐私模式，状态变化时记录日志并重启管线切换到占位画面或恢复采集
+func (c *baseCapturer) updatePrivacy() {
+	scheduled := config.PrivacyScheduled(c.config.PrivacySchedules, time.Now())
+
+	c.privacyMu.Lock()
+	var active bool
+	var reason string
+	switch c.privacyMode {
+	case PrivacyOn:
+		active, reason = true, "手动开启"
+	case PrivacyOff:
+		active, reason = false, "手动关闭"
+	default:
+		active = scheduled
+		if scheduled {
+			reason = "计划时段"
+		} else {
+			reason = "不在计划时段"
+		}
+	}
+	changed := active != c.privacyActive
+	if changed {
+		c.privacyActive = active
+		c.privacySince = time.Now()
+	}
+	c.privacyMu.Unlock()
+
+	if !changed {
+		return
+	}
+	if active {
+		log.Printf("摄像头 %s 进入隐私模式（%s），停止采集和录像", c.config.ID, reason)
+	} else {
+		log.Printf("摄像头 %s 退出隐私模式（%s），恢复采集", c.config.ID, reason)
+	}
+	c.supervisor.rerun()
+}
+
+// watchPrivacySchedule 定期检查计划时段，直到 ctx 取消
+func (c *baseCapturer) watchPrivacySchedule(ctx context.Context) {
+	ticker := time.NewTicker(privacyCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.updatePrivacy()
+		}
+	}
+}
+
+// runOrPrivacy 隐私模式下运行占位画面，否则运行采集管线
+func (c *baseCapturer) runOrPrivacy(ctx context.Context) error {
+	if c.inPrivacy() {
+		return c.runPrivacy(ctx)
+	}
+	return c.run(ctx)
+}
+
+// runPrivacy 隐私模式：不连接来源，按帧率向各档位广播占位画面并广播静音，
+// 订阅者（MJPEG、WebRTC、HLS、RTMP）保持连接，退出隐私模式后无缝恢复
+func (c *baseCapturer) runPrivacy(ctx context.Context) error {
+	placeholders := make(map[string][]byte, len(c.profileList))
+	for _, profile := range c.profileList {
+		width, height := c.profileSize(profile)
+		data, err := placeholderJPEG(width, height)
+		if err != nil {
+			return err
+		}
+		placeholders[profile] = data
+	}
+
+	fps := c.config.FPS
+	if fps <= 0 {
+		fps = 5
+	}
+	frameTicker := time.NewTicker(time.Second / time.Duration(fps))
+	defer frameTicker.Stop()
+	audioTicker := time.NewTicker(placeholderAudioInterval)
+	defer audioTicker.Stop()
+	silence := make([]byte, audioSampleRate*int(placeholderAudioInterval/time.Millisecond)/1000*audioChannels*2)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-frameTicker.C:
+			for _, profile := range c.profileList {
+				frame := wrapFrame(placeholders[profile])
+				c.broadcastProfileFrame(profile, frame)
+				frame.Release()
+			}
+		case <-audioTicker.C:
+			c.broadcastAudio(silence)
+		}
+	}
+}
+
+// profileSize 档位的画面尺寸：优先使用最近一帧的尺寸，其次为配置尺寸
+func (c *baseCapturer) profileSize(profile string) (int, int) {
+	state := c.profiles[profile]
+
+	c.lastFrameMu.RLock()
+	if state.lastFrame != nil {
+		meta := state.lastFrame.meta
+		c.lastFrameMu.RUnlock()
+		if meta.Width > 0 && meta.Height > 0 {
+			return meta.Width, meta.Height
+		}
+	} else {
+		c.lastFrameMu.RUnlock()
+	}
+
+	width, height := state.width, state.height
+	if profile == MainProfile {
+		width, height = c.VideoSize()
+	}
+	if width <= 0 || height <= 0 {
+		return placeholderWidth, placeholderHeight
+	}
+	return width, height
+}
+
+// placeholderGlyphs "PRIVACY" 的 5x7 点阵字形
+var placeholderGlyphs = [][7]string{
+	{"11110", "10001", "10001", "11110", "10000", "10000", "10000"}, // P
+	{"11110", "10001", "10001", "11110", "10100", "10010", "10001"}, // R
+	{"01110", "00100", "00100", "00100", "00100", "00100", "01110"}, // I
+	{"10001", "10001", "10001", "10001", "10001", "01010", "00100"}, // V
+	{"01110", "10001", "10001", "11111", "10001", "10001", "10001"}, // A
+	{"01110", "10001", "10000", "10000", "10000", "10001", "01110"}, // C
+	{"10001", "10001", "01010", "00100", "00100", "00100", "00100"}, // Y
+}
+
+// placeholderJPEG 生成隐私模式占位画面：深灰背景，居中显示 PRIVACY
+func placeholderJPEG(width, height int) ([]byte, error) {
+	img := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio420)
+	for i := range img.Y {
+		img.Y[i] = 0x30
+	}
+	for i := range img.Cb {
+		img.Cb[i] = 0x80
+		img.Cr[i] = 0x80
+	}
+
+	// 每个字形 5 列 + 1 列间距，文字宽度约为画面的一半
+	cols := len(placeholderGlyphs)*6 - 1
+	scale := max(1, width/2/cols)
+	left := (width - cols*scale) / 2
+	top := (height - 7*scale) / 2
+	text := color.YCbCr{Y: 0xD0, Cb: 0x80, Cr: 0x80}
+	for g, glyph := range placeholderGlyphs {
+		for row, bits := range glyph {
+			for col, bit := range bits {
+				if bit != '1' {
+					continue
+				}
+				x0 := left + (g*6+col)*scale
+				y0 := top + row*scale
+				for y := max(y0, 0); y < min(y0+scale, height); y++ {
+					for x := max(x0, 0); x < min(x0+scale, width); x++ {
+						img.Y[img.YOffset(x, y)] = text.Y
+					}
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("生成占位画面失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,137 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"slices"
+	"time"
+
+	"home-monitor/internal/config"
+)
+
+// ffprobe 探测超时
+const probeTimeout = 10 * time.Second
+
+// RecordingMode 录像编码方式
+type RecordingMode string
+
+const (
+	RecordingCopy      RecordingMode = "copy"      // 复制原始码流，只重新封装
+	RecordingTranscode RecordingMode = "transcode" // 解码后用 libx264 重新编码
+)
+
+// RecordingStatus 录像编码方式及选择依据
+type RecordingStatus struct {
+	Mode       RecordingMode `json:"mode"`
+	VideoCodec string        `json:"video_codec,omitempty"` // ffprobe 探测到的源视频编码
+	AudioCodec string        `json:"audio_codec,omitempty"` // ffprobe 探测到的源音频编码
+	CopyAudio  bool          `json:"copy_audio"`            // 音频是否也直接复制
+	Reason     string        `json:"reason,omitempty"`      // 选择该方式的原因
+
+	// 探测失败（源暂不可达等），下次启动管线时重新探测
+	probeFailed bool
+}
+
+// 各录像容器可直接复制的编码（ffprobe codec_name）
+var (
+	copyVideoCodecs = map[string][]string{
+		"mp4": {"h264", "hevc"},
+		"mov": {"h264", "hevc"},
+		"mkv": {"h264", "hevc"},
+		"avi": {"h264"},
+	}
+	copyAudioCodecs = map[string][]string{
+		"mp4": {"aac"},
+		"mov": {"aac"},
+		"mkv": {"aac", "opus", "mp3"},
+		"avi": {"mp3"},
+	}
+)
+
+// probeResult ffprobe -of json 的输出
+type probeResult struct {
+	Streams []struct {
+		CodecType string `json:"codec_type"`
+		CodecName string `json:"codec_name"`
+	} `json:"streams"`
+}
+
+// probeCodecs 用 ffprobe 探测输入的视频和音频编码，input 为输入参数（含 -i）
+func probeCodecs(ctx context.Context, input []string) (video, audio string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	args := []string{"-v", "error"}
+	args = append(args, input...)
+	args = append(args,
+		"-show_entries", "stream=codec_type,codec_name",
+		"-of", "json",
+	)
+
+	output, err := exec.CommandContext(ctx, "ffprobe", args...).Output()
+	if err != nil {
+		return "", "", fmt.Errorf("ffprobe 探测失败: %w", err)
+	}
+
+	var result probeResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return "", "", fmt.Errorf("解析 ffprobe 输出失败: %w", err)
+	}
+	for _, stream := range result.Streams {
+		switch {
+		case stream.CodecType == "video" && video == "":
+			video = stream.CodecName
+		case stream.CodecType == "audio" && audio == "":
+			audio = stream.CodecName
+		}
+	}
+	if video == "" {
+		return "", "", fmt.Errorf("源中没有视频流")
+	}
+	return video, audio, nil
+}
+
+// selectRecordingMode 按配置和源编码选择录像编码方式，input 为当前源的输入参数
+// 只有 rtsp 源会复制码流；auto 模式下探测失败或编码与容器不兼容时回退到转码
+func selectRecordingMode(ctx context.Context, cfg config.CameraConfig, input []string, format string) RecordingStatus {
+	if cfg.Type != "rtsp" {
+		return RecordingStatus{Mode: RecordingTranscode, Reason: "仅 rtsp 源支持复制码流"}
+	}
+
+	if len(cfg.PrivacyMasks) > 0 {
+		return RecordingStatus{Mode: RecordingTranscode, Reason: "配置了隐私遮挡，需要重新编码"}
+	}
+	if cfg.OSD.Enabled {
+		return RecordingStatus{Mode: RecordingTranscode, Reason: "配置了 OSD，需要重新编码"}
+	}
+	if cfg.Transform.Enabled() {
+		return RecordingStatus{Mode: RecordingTranscode, Reason: "配置了画面变换，需要重新编码"}
+	}
+
+	switch cfg.RecordMode {
+	case config.RecordModeTranscode:
+		return RecordingStatus{Mode: RecordingTranscode, Reason: "配置为转码"}
+	case config.RecordModeCopy:
+		// 不探测，音频统一转为 AAC
+		return RecordingStatus{Mode: RecordingCopy, Reason: "配置为复制"}
+	}
+
+	video, audio, err := probeCodecs(ctx, input)
+	if err != nil {
+		return RecordingStatus{Mode: RecordingTranscode, Reason: err.Error(), probeFailed: true}
+	}
+
+	status := RecordingStatus{VideoCodec: video, AudioCodec: audio}
+	if !slices.Contains(copyVideoCodecs[format], video) {
+		status.Mode = RecordingTranscode
+		status.Reason = fmt.Sprintf("视频编码 %s 不能直接写入 %s", video, format)
+		return status
+	}
+
+	status.Mode = RecordingCopy
+	status.CopyAudio = slices.Contains(copyAudioCodecs[format], audio)
+	status.Reason = fmt.Sprintf("视频编码 %s 与 %s 兼容", video, format)
+	return status
+}
@@ -0,0 +1,199 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"home-monitor/internal/config"
+	"home-monitor/internal/ffmpeglog"
+)
+
+// FramePusher 接收外部推送帧的采集器
+// 调用方移交 frame 的所有权，之后不得再修改
+type FramePusher interface {
+	PushFrame(frame []byte) error
+}
+
+// PushCapturer 推送型采集器
+// 设备（ESP32、旧手机脚本等）通过 HTTP/WebSocket 主动推送 JPEG 帧，
+// 帧直接注入分发链路；配置了录制时由 FFmpeg 从 stdin 读取推送帧编码为分段录像
+type PushCapturer struct {
+	baseCapturer
+
+	// 录制配置与转码档位
+	recordingConfig *RecordingConfig
+	recordEncoder   config.EncoderProfile
+
+	// 隐私遮挡（未配置时为 nil），遮挡后的帧同时用于分发和录像
+	privacy *privacyMasker
+	// 画面叠加文字（未启用时为 nil），只叠加到录像
+	osd *osdOverlay
+
+	// 录像编码器输入队列（编码器运行期间非空）
+	recordCh    chan *Frame
+	recordMutex sync.RWMutex
+}
+
+// newPushCapturer 创建推送型采集器
+func newPushCapturer(cfg config.CameraConfig, opts captureOptions) *PushCapturer {
+	c := &PushCapturer{
+		recordingConfig: opts.recording,
+		recordEncoder:   opts.encoders.Resolve(cfg.Encoders.Record, config.EncoderRecord),
+		privacy:         newPrivacyMasker(cfg.PrivacyMasks),
+		osd:             newOSDOverlay(cfg, opts.osdText),
+	}
+	c.init(cfg, opts.privacyMode, c.run)
+	return c
+}
+
+// HasAudio 推送来源不含音频
+func (c *PushCapturer) HasAudio() bool {
+	return false
+}
+
+// PushFrame 注入一帧 JPEG
+func (c *PushCapturer) PushFrame(frame []byte) error {
+	if !c.IsRunning() {
+		return fmt.Errorf("采集器未运行")
+	}
+	if len(frame) > httpMaxFrameSize {
+		return fmt.Errorf("帧超过 %d 字节", httpMaxFrameSize)
+	}
+	if !bytes.HasPrefix(frame, []byte{0xFF, 0xD8}) {
+		return fmt.Errorf("不是有效的 JPEG")
+	}
+	if c.inPrivacy() {
+		// 隐私模式下丢弃推送帧，设备无需感知
+		return nil
+	}
+
+	// 请求体已是独立的缓冲区，直接包装共享，无需复制
+	f, err := c.privacy.apply(wrapFrame(frame))
+	if err != nil {
+		return err
+	}
+	defer f.Release()
+	c.broadcastFrame(f)
+
+	c.recordMutex.RLock()
+	defer c.recordMutex.RUnlock()
+	if c.recordCh != nil {
+		select {
+		case c.recordCh <- f.Retain():
+		default:
+			// 编码器跟不上，丢弃
+			f.Release()
+		}
+	}
+	return nil
+}
+
+// setOSDText 更新 OSD 动态文本，录像编码器下一帧起生效
+func (c *PushCapturer) setOSDText(text string) error {
+	if c.osd == nil {
+		return fmt.Errorf("未启用 OSD")
+	}
+	return c.osd.setText(text)
+}
+
+// run 无录制时仅等待停止；有录制时运行录像编码器，编码器退出后由 supervisor 重启
+func (c *PushCapturer) run(ctx context.Context) error {
+	if c.recordingConfig == nil {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return c.runRecorder(ctx)
+}
+
+// runRecorder 运行一次录像编码进程，推送帧按到达时间打时间戳
+func (c *PushCapturer) runRecorder(ctx context.Context) error {
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "level+warning",
+		"-f", "mjpeg",
+		"-use_wallclock_as_timestamps", "1",
+		"-i", "pipe:0",
+		"-an",
+	}
+	if c.osd != nil {
+		if err := c.osd.write(); err != nil {
+			return err
+		}
+		args = append(args, "-vf", c.osd.filter())
+	}
+	args = append(args, c.recordEncoder.VideoArgs(c.config.FPS)...)
+	args = append(args, segmentOutputArgs(c.recordingConfig, c.config.ID)...)
+
+	cmd := exec.Command("ffmpeg", args...)
+	cmd.Stderr = ffmpeglog.Writer(c.config.ID, ffmpeglog.PipelineRecording)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("创建编码器输入管道失败: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("启动录像编码器失败: %w", err)
+	}
+
+	frames := make(chan *Frame, 30)
+	c.recordMutex.Lock()
+	c.recordCh = frames
+	c.recordMutex.Unlock()
+	defer func() {
+		c.recordMutex.Lock()
+		c.recordCh = nil
+		c.recordMutex.Unlock()
+		// 释放未写入的帧
+		for {
+			select {
+			case frame := <-frames:
+				frame.Release()
+			default:
+				return
+			}
+		}
+	}()
+
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+	}()
+
+	// 写入 goroutine：ctx 取消或写入失败时关闭 stdin，FFmpeg 读到 EOF 后收尾退出
+	writerCtx, stopWriter := context.WithCancel(ctx)
+	defer stopWriter()
+	go func() {
+		defer stdin.Close()
+		for {
+			select {
+			case <-writerCtx.Done():
+				return
+			case frame := <-frames:
+				_, err := stdin.Write(frame.Bytes())
+				frame.Release()
+				if err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		// 关闭 stdin 后等待分段正常收尾，超时再中断
+		select {
+		case <-waitCh:
+		case <-time.After(gracefulStopTimeout):
+			stopProcess(cmd, waitCh)
+		}
+		return ctx.Err()
+	case err := <-waitCh:
+		if err == nil {
+			return fmt.Errorf("录像编码器已退出")
+		}
+		return fmt.Errorf("录像编码器已退出: %w", err)
+	}
+}
@@ -0,0 +1,283 @@
+package capture
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DropPolicy 订阅者缓冲区满时的处理策略
+type DropPolicy string
+
+const (
+	// DropOldest 丢弃缓冲区中最旧的数据，保留最新数据（适合预览）
+	DropOldest DropPolicy = "drop_oldest"
+	// DropNewest 丢弃新到的数据，保留已排队的数据（适合需要连续性的音频）
+	DropNewest DropPolicy = "drop_newest"
+	// LatestOnly 缓冲区只保留最新一份（适合快照、低延迟预览）
+	LatestOnly DropPolicy = "latest_only"
+	// BoundedBlock 缓冲区满时阻塞等待，超时后丢弃新数据（适合编码器）
+	// 阻塞型订阅者在其他订阅者之后投递，不影响预览的实时性
+	BoundedBlock DropPolicy = "bounded_block"
+)
+
+// 阻塞型订阅者默认的最长等待时间
+const defaultBlockTimeout = 200 * time.Millisecond
+
+// SubscribeOption 订阅选项
+type SubscribeOption func(*subscribeOptions)
+
+// subscribeOptions 订阅参数
+type subscribeOptions struct {
+	policy       DropPolicy
+	bufferSize   int
+	blockTimeout time.Duration
+	label        string
+	profile      string // 视频预览档位，音频订阅忽略
+}
+
+// WithPolicy 设置缓冲区满时的处理策略
+func WithPolicy(policy DropPolicy) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.policy = policy
+	}
+}
+
+// WithBufferSize 设置缓冲区大小
+func WithBufferSize(size int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.bufferSize = size
+	}
+}
+
+// WithBlockTimeout 设置 BoundedBlock 策略的最长等待时间
+func WithBlockTimeout(timeout time.Duration) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.blockTimeout = timeout
+	}
+}
+
+// WithLabel 设置订阅者描述（如观看者地址），用于统计展示
+func WithLabel(label string) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.label = label
+	}
+}
+
+// WithProfile 订阅指定预览档位的帧，默认 main；对音频订阅无效
+func WithProfile(profile string) SubscribeOption {
+	return func(o *subscribeOptions) {
+		if profile != "" {
+			o.profile = profile
+		}
+	}
+}
+
+// SubscriberStats 订阅者投递统计
+type SubscriberStats struct {
+	ID         string     `json:"id"`
+	Label      string     `json:"label,omitempty"`
+	Kind       string     `json:"kind"` // video, audio
+	Profile    string     `json:"profile,omitempty"`
+	Policy     DropPolicy `json:"policy"`
+	BufferSize int        `json:"buffer_size"`
+	QueueDepth int        `json:"queue_depth"` // 当前排队数量，接近 buffer_size 说明消费跟不上
+	Delivered  uint64     `json:"delivered"`
+	Dropped    uint64     `json:"dropped"`
+	Since      time.Time  `json:"since"`
+}
+
+// subscriber 单个订阅者：缓冲通道、策略与计数
+type subscriber[T any] struct {
+	id      string
+	kind    string
+	ch      chan T
+	opts    subscribeOptions
+	release func(T) // 丢弃或关闭时释放数据，nil 表示无需释放
+	since   time.Time
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+
+	// 投递在订阅表锁外进行，sendMu 保证关闭后不再写入通道
+	sendMu sync.Mutex
+	closed bool
+}
+
+// newSubscriber 按默认值和调用方选项创建订阅者
+func newSubscriber[T any](id, kind string, defaults subscribeOptions, opts []SubscribeOption, release func(T)) *subscriber[T] {
+	o := defaults
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	switch o.policy {
+	case DropOldest, DropNewest, BoundedBlock:
+	case LatestOnly:
+		o.bufferSize = 1
+	default:
+		o.policy = defaults.policy
+	}
+	if o.bufferSize <= 0 {
+		o.bufferSize = defaults.bufferSize
+	}
+	if o.blockTimeout <= 0 {
+		o.blockTimeout = defaultBlockTimeout
+	}
+	if defaults.profile == "" {
+		o.profile = ""
+	}
+
+	return &subscriber[T]{
+		id:      id,
+		kind:    kind,
+		ch:      make(chan T, o.bufferSize),
+		opts:    o,
+		release: release,
+		since:   time.Now(),
+	}
+}
+
+// blocking 是否为阻塞型订阅者
+func (s *subscriber[T]) blocking() bool {
+	return s.opts.policy == BoundedBlock
+}
+
+// send 按策略投递，未送达的数据在此释放；已关闭时直接丢弃
+func (s *subscriber[T]) send(v T) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.closed {
+		s.drop(v)
+		return
+	}
+
+	select {
+	case s.ch <- v:
+		s.delivered.Add(1)
+		return
+	default:
+	}
+
+	switch s.opts.policy {
+	case DropOldest, LatestOnly:
+		select {
+		case old := <-s.ch:
+			s.drop(old)
+		default:
+		}
+		select {
+		case s.ch <- v:
+			s.delivered.Add(1)
+		default:
+			s.drop(v)
+		}
+	case BoundedBlock:
+		timer := time.NewTimer(s.opts.blockTimeout)
+		defer timer.Stop()
+		select {
+		case s.ch <- v:
+			s.delivered.Add(1)
+		case <-timer.C:
+			s.drop(v)
+		}
+	default:
+		s.drop(v)
+	}
+}
+
+// drop 丢弃一份数据
+func (s *subscriber[T]) drop(v T) {
+	s.dropped.Add(1)
+	if s.release != nil {
+		s.release(v)
+	}
+}
+
+// close 关闭通道并释放未取走的数据，正在进行的阻塞投递结束后才关闭
+// 调用方应先将订阅者移出订阅表并释放表锁，避免阻塞其他订阅者的投递
+func (s *subscriber[T]) close() {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+	for v := range s.ch {
+		if s.release != nil {
+			s.release(v)
+		}
+	}
+}
+
+// stats 获取统计快照
+func (s *subscriber[T]) stats() SubscriberStats {
+	return SubscriberStats{
+		ID:         s.id,
+		Label:      s.opts.label,
+		Kind:       s.kind,
+		Profile:    s.opts.profile,
+		Policy:     s.opts.policy,
+		BufferSize: s.opts.bufferSize,
+		QueueDepth: len(s.ch),
+		Delivered:  s.delivered.Load(),
+		Dropped:    s.dropped.Load(),
+		Since:      s.since,
+	}
+}
+
+// selectSubscribers 在持有订阅表锁时取出订阅了 profile 的订阅者（profile 为空时为所有订阅者），阻塞型订阅者排在最后
+func selectSubscribers[T any](subs map[string]*subscriber[T], profile string) []*subscriber[T] {
+	if len(subs) == 0 {
+		return nil
+	}
+	selected := make([]*subscriber[T], 0, len(subs))
+	for _, sub := range subs {
+		if !sub.blocking() && sub.matches(profile) {
+			selected = append(selected, sub)
+		}
+	}
+	for _, sub := range subs {
+		if sub.blocking() && sub.matches(profile) {
+			selected = append(selected, sub)
+		}
+	}
+	return selected
+}
+
+// fanOut 按顺序向订阅者投递，value 为每个订阅者生成一份数据
+// 在订阅表锁外调用：阻塞型订阅者等待期间不影响订阅、取消订阅和其他档位的广播
+func fanOut[T any](subs []*subscriber[T], value func() T) {
+	for _, sub := range subs {
+		sub.send(value())
+	}
+}
+
+// closeSubscribers 关闭一组已移出订阅表的订阅者
+func closeSubscribers[T any](subs []*subscriber[T]) {
+	for _, sub := range subs {
+		sub.close()
+	}
+}
+
+// matches 是否订阅了该档位
+func (s *subscriber[T]) matches(profile string) bool {
+	return profile == "" || s.opts.profile == profile
+}
+
+// collectStats 收集一组订阅者的统计
+func collectStats[T any](stats []SubscriberStats, subs map[string]*subscriber[T]) []SubscriberStats {
+	for _, sub := range subs {
+		stats = append(stats, sub.stats())
+	}
+	return stats
+}
+
+// sortStats 按订阅时间排序
+func sortStats(stats []SubscriberStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Since.Before(stats[j].Since)
+	})
+}
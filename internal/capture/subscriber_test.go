@@ -0,0 +1,82 @@
+package capture
+
+import (
+	"testing"
+	"time"
+
+	"home-monitor/internal/config"
+)
+
+// newTestCapturer 创建只用于广播测试的采集器（不启动管线）
+func newTestCapturer(t testing.TB, profiles ...string) *baseCapturer {
+	t.Helper()
+	c := &baseCapturer{}
+	c.init(config.CameraConfig{ID: "test"}, PrivacyAuto, nil)
+	for _, p := range profiles {
+		c.addProfile(p, 0, 0)
+	}
+	return c
+}
+
+// TestBlockingSubscriberOutsideLock 阻塞型订阅者等待期间，订阅变更和其他档位的广播不受影响
+func TestBlockingSubscriberOutsideLock(t *testing.T) {
+	c := newTestCapturer(t, "sub")
+	jpeg := encodeTestJPEG(t, 16, 16)
+
+	// 缓冲区为 1 且不消费：第一帧排队，第二帧阻塞 blockTimeout
+	const blockTimeout = time.Second
+	c.SubscribeFrameRefs("hls", WithPolicy(BoundedBlock), WithBufferSize(1), WithBlockTimeout(blockTimeout))
+
+	first := newFrame(jpeg)
+	c.broadcastProfileFrame(MainProfile, first)
+	first.Release()
+
+	blocked := make(chan struct{})
+	go func() {
+		defer close(blocked)
+		second := newFrame(jpeg)
+		c.broadcastProfileFrame(MainProfile, second)
+		second.Release()
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	viewer := c.SubscribeFrameRefs("viewer", WithProfile("sub"))
+	c.UnsubscribeFrames("missing")
+
+	other := newFrame(jpeg)
+	c.broadcastProfileFrame("sub", other)
+	other.Release()
+
+	select {
+	case f := <-viewer:
+		f.Release()
+	case <-time.After(blockTimeout / 2):
+		t.Fatal("其他档位的广播被阻塞型订阅者阻塞")
+	}
+	if elapsed := time.Since(start); elapsed > blockTimeout/2 {
+		t.Fatalf("订阅和广播耗时 %v，被阻塞型订阅者阻塞", elapsed)
+	}
+
+	// 阻塞投递期间取消订阅：等待投递超时后关闭，不会向已关闭的通道写入
+	c.UnsubscribeFrameRefs("hls")
+	<-blocked
+	c.UnsubscribeFrameRefs("viewer")
+}
+
+// TestSubscriberClosedDrop 关闭后的投递直接丢弃并释放
+func TestSubscriberClosedDrop(t *testing.T) {
+	sub := newSubscriber("s", "video", defaultFrameOptions, nil, (*Frame).Release)
+	sub.close()
+	sub.close() // 重复关闭无副作用
+
+	f := wrapFrame([]byte{0xFF, 0xD8})
+	sub.send(f.Retain())
+	if got := f.refs.Load(); got != 1 {
+		t.Fatalf("关闭后投递未释放引用: refs = %d", got)
+	}
+	if got := sub.dropped.Load(); got != 1 {
+		t.Fatalf("dropped = %d, want 1", got)
+	}
+	f.Release()
+}
@@ -0,0 +1,285 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// State 采集管线状态
+type State string
+
+const (
+	StateStopped  State = "stopped"  // 未启动或已停止
+	StateStarting State = "starting" // 进程已启动，等待首帧
+	StateRunning  State = "running"  // 正常出帧
+	StateBackoff  State = "backoff"  // 进程退出，等待重启
+	StateStalled  State = "stalled"  // 画面停滞，等待重启
+	StateFailed   State = "failed"   // 不可恢复的错误，不再重启
+)
+
+// 重启退避参数
+const (
+	backoffInitial = 1 * time.Second
+	backoffMax     = 60 * time.Second
+	backoffJitter  = 0.2 // ±20% 抖动
+	// 连续运行超过该时长视为稳定，退避重新从初始值开始
+	stableRunPeriod = 30 * time.Second
+)
+
+// Status 采集器运行状态
+type Status struct {
+	State        State     `json:"state"`
+	RestartCount int       `json:"restart_count"`
+	LastError    string    `json:"last_error,omitempty"`
+	LastExitAt   time.Time `json:"last_exit_at"`
+	StartedAt    time.Time `json:"started_at"`
+	NextRetryAt  time.Time `json:"next_retry_at"`
+
+	// 帧看门狗
+	Stalled      bool      `json:"stalled"`        // 当前是否处于画面停滞
+	StallCount   int       `json:"stall_count"`    // 累计停滞次数
+	LastFrameAt  time.Time `json:"last_frame_at"`  // 最近一帧的广播时间
+	LastFrameAge float64   `json:"last_frame_age"` // 距最近一帧的秒数，无帧时为 -1
+
+	// MJPEG 流解析计数（仅 FFmpeg 采集器）
+	Parser *ParserStats `json:"parser,omitempty"`
+
+	// 当前使用的源地址（仅 rtsp，已隐藏密码）
+	Source string `json:"source,omitempty"`
+	// 预览使用的子码流地址（仅配置了 sub_url 的 rtsp，已隐藏密码）
+	SubSource string `json:"sub_source,omitempty"`
+
+	// 录像编码方式（仅启用录像的 FFmpeg 采集器）
+	Recording *RecordingStatus `json:"recording,omitempty"`
+
+	// 隐私模式，处于隐私模式时不采集、不录像，输出占位画面
+	Privacy PrivacyStatus `json:"privacy"`
+}
+
+// runFunc 运行一次采集管线，阻塞直到管线退出
+type runFunc func(ctx context.Context) error
+
+// supervisor 采集管线监督器
+// 管线退出后按指数退避 + 抖动自动重启，订阅者在重启期间保持不变
+type supervisor struct {
+	name string
+	run  runFunc
+
+	// 无帧超过该时长视为停滞，<= 0 表示禁用看门狗
+	stallTimeout time.Duration
+
+	// 当前运行的取消函数，以及是否为 rerun 主动结束
+	cancelRun context.CancelFunc
+	rerunning bool
+	// rerun 唤醒退避等待
+	wake chan struct{}
+	// 启动前的准备工作（如 ffprobe 探测）期间暂停停滞检测
+	watchdogPaused bool
+
+	status Status
+	mutex  sync.RWMutex
+}
+
+// newSupervisor 创建监督器
+func newSupervisor(name string, stallTimeout time.Duration, run runFunc) *supervisor {
+	return &supervisor{
+		name:         name,
+		run:          run,
+		stallTimeout: stallTimeout,
+		wake:         make(chan struct{}, 1),
+		status:       Status{State: StateStopped},
+	}
+}
+
+// rerun 立即结束当前运行并重新启动管线，不计入错误和退避（用于切换隐私模式）
+// 处于退避等待时直接结束等待
+func (s *supervisor) rerun() {
+	s.mutex.Lock()
+	cancel := s.cancelRun
+	s.rerunning = cancel != nil
+	s.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+		return
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// rerunRequested 当前运行是否正在被 rerun 主动结束
+func (s *supervisor) rerunRequested() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.rerunning
+}
+
+// loop 监督循环，直到 ctx 取消或发生不可恢复错误
+func (s *supervisor) loop(ctx context.Context) {
+	backoff := backoffInitial
+
+	s.mutex.Lock()
+	s.status = Status{State: StateStarting}
+	s.mutex.Unlock()
+
+	for {
+		// 丢弃上次运行期间的唤醒
+		select {
+		case <-s.wake:
+		default:
+		}
+
+		// 每次运行使用独立的 context，看门狗检测到停滞时取消它以触发重启
+		runCtx, cancelRun := context.WithCancel(ctx)
+		stalled := make(chan time.Duration, 1)
+
+		startedAt := time.Now()
+		s.mutex.Lock()
+		s.status.State = StateStarting
+		s.status.StartedAt = startedAt
+		s.status.NextRetryAt = time.Time{}
+		s.cancelRun = cancelRun
+		s.rerunning = false
+		s.mutex.Unlock()
+
+		go s.watch(runCtx, cancelRun, stalled)
+
+		err := s.run(runCtx)
+		cancelRun()
+
+		s.mutex.Lock()
+		s.cancelRun = nil
+		rerunning := s.rerunning
+		s.mutex.Unlock()
+
+		if ctx.Err() != nil {
+			s.setState(StateStopped)
+			return
+		}
+		if rerunning {
+			// 主动切换，立即重新启动
+			continue
+		}
+
+		select {
+		case age := <-stalled:
+			err = fmt.Errorf("画面停滞 %v 无新帧", age.Round(time.Second))
+		default:
+			if err == nil {
+				err = errors.New("FFmpeg 进程意外退出")
+			}
+		}
+
+		s.mutex.Lock()
+		s.status.LastError = err.Error()
+		s.status.LastExitAt = time.Now()
+		s.mutex.Unlock()
+
+		if isPermanentError(err) {
+			log.Printf("采集器 %s 发生不可恢复错误，停止重启: %v", s.name, err)
+			s.setState(StateFailed)
+			<-ctx.Done()
+			s.setState(StateStopped)
+			return
+		}
+
+		// 运行足够久则重置退避
+		if time.Since(startedAt) >= stableRunPeriod {
+			backoff = backoffInitial
+		}
+
+		wait := jitter(backoff)
+		s.mutex.Lock()
+		if s.status.Stalled {
+			s.status.State = StateStalled
+		} else {
+			s.status.State = StateBackoff
+		}
+		s.status.NextRetryAt = time.Now().Add(wait)
+		s.mutex.Unlock()
+
+		log.Printf("采集器 %s 已退出 (%v)，%v 后重启", s.name, err, wait.Round(time.Millisecond))
+
+		select {
+		case <-ctx.Done():
+			s.setState(StateStopped)
+			return
+		case <-time.After(wait):
+		case <-s.wake:
+		}
+
+		backoff *= 2
+		if backoff > backoffMax {
+			backoff = backoffMax
+		}
+
+		s.mutex.Lock()
+		s.status.RestartCount++
+		s.mutex.Unlock()
+	}
+}
+
+// pauseWatchdog 暂停本次运行的停滞检测，用于启动前耗时的准备工作（如 ffprobe 探测）
+// 返回的函数恢复检测，并从当前时间重新开始停滞计时
+func (s *supervisor) pauseWatchdog() (resume func()) {
+	s.mutex.Lock()
+	s.watchdogPaused = true
+	s.mutex.Unlock()
+
+	return func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.watchdogPaused = false
+		s.status.StartedAt = time.Now()
+	}
+}
+
+// noteFrame 记录一帧已广播，收到首帧后标记为运行中并清除停滞标记
+func (s *supervisor) noteFrame() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status.LastFrameAt = time.Now()
+	s.status.Stalled = false
+	if s.status.State == StateStarting {
+		s.status.State = StateRunning
+	}
+}
+
+// setState 设置状态
+func (s *supervisor) setState(state State) {
+	s.mutex.Lock()
+	s.status.State = state
+	s.mutex.Unlock()
+}
+
+// getStatus 获取状态快照
+func (s *supervisor) getStatus() Status {
+	s.mutex.RLock()
+	status := s.status
+	s.mutex.RUnlock()
+
+	status.LastFrameAge = -1
+	if !status.LastFrameAt.IsZero() {
+		status.LastFrameAge = time.Since(status.LastFrameAt).Seconds()
+	}
+	return status
+}
+
+// isPermanentError 判断是否为重启也无法恢复的错误
+func isPermanentError(err error) bool {
+	return errors.Is(err, exec.ErrNotFound)
+}
+
+// jitter 对等待时间加入 ±backoffJitter 的随机抖动
+func jitter(d time.Duration) time.Duration {
+	delta := (rand.Float64()*2 - 1) * backoffJitter * float64(d)
+	return d + time.Duration(delta)
+}
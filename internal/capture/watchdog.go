@@ -0,0 +1,58 @@
+package capture
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// watchdogMinInterval 看门狗最小检查间隔
+const watchdogMinInterval = 500 * time.Millisecond
+
+// watch 帧看门狗：运行期间定期检查最近一帧的时间，
+// 超过 stallTimeout 未出帧则标记停滞并取消本次运行，由监督循环重启管线
+func (s *supervisor) watch(ctx context.Context, cancelRun context.CancelFunc, stalled chan<- time.Duration) {
+	if s.stallTimeout <= 0 {
+		return
+	}
+
+	interval := s.stallTimeout / 4
+	if interval < watchdogMinInterval {
+		interval = watchdogMinInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mutex.Lock()
+		if s.watchdogPaused {
+			s.mutex.Unlock()
+			continue
+		}
+		// 本次运行尚未出帧时，从启动时间开始计算
+		since := s.status.StartedAt
+		if s.status.LastFrameAt.After(since) {
+			since = s.status.LastFrameAt
+		}
+		age := time.Since(since)
+		if age < s.stallTimeout {
+			s.mutex.Unlock()
+			continue
+		}
+		s.status.State = StateStalled
+		s.status.Stalled = true
+		s.status.StallCount++
+		s.mutex.Unlock()
+
+		log.Printf("⚠️ 采集器 %s 画面停滞 %v，重启采集管线", s.name, age.Round(time.Second))
+		stalled <- age
+		cancelRun()
+		return
+	}
+}
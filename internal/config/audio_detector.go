@@ -0,0 +1,107 @@
+package config
+
+import "fmt"
+
+// 音频检测器类型
+const (
+	AudioDetectThreshold = "threshold" // 电平超过阈值并持续最短时长（如玻璃破碎、撞击声）
+	AudioDetectSustained = "sustained" // 电平持续高于环境基线（如婴儿哭声、警报声）
+	AudioDetectSilence   = "silence"   // 出现声音后恢复安静并持续最短时长
+)
+
+// 每个摄像头最多的音频检测器数量
+const maxAudioDetectors = 8
+
+// AudioDetector 音频事件检测器，按 20ms 音频块的 RMS 电平判断
+type AudioDetector struct {
+	Name string `yaml:"name" json:"name"`
+	Type string `yaml:"type" json:"type"` // threshold, sustained, silence
+	// 电平阈值（dBFS，-96 到 0）：threshold 为触发电平，silence 为区分有声和安静的电平
+	ThresholdDBFS float64 `yaml:"threshold_dbfs" json:"threshold_dbfs"`
+	// 高于基线的分贝数（sustained），默认 10
+	MarginDB float64 `yaml:"margin_db" json:"margin_db"`
+	// 基线（环境噪声）的平均时长（秒，sustained），基线建立前不触发，默认 60
+	BaselineSeconds int `yaml:"baseline_seconds" json:"baseline_seconds"`
+	// 条件持续多久才触发（毫秒），默认 threshold 100、sustained 5000、silence 10000
+	MinDurationMs int `yaml:"min_duration_ms" json:"min_duration_ms"`
+	// 同一检测器两次事件的最小间隔（秒），默认 30
+	Cooldown int `yaml:"cooldown" json:"cooldown"`
+}
+
+// setAudioDetectorDefaults 设置音频检测器默认值
+func setAudioDetectorDefaults(d *AudioDetector) {
+	if d.MinDurationMs == 0 {
+		switch d.Type {
+		case AudioDetectThreshold:
+			d.MinDurationMs = 100
+		case AudioDetectSustained:
+			d.MinDurationMs = 5000
+		case AudioDetectSilence:
+			d.MinDurationMs = 10000
+		}
+	}
+	if d.Type == AudioDetectSustained {
+		if d.MarginDB == 0 {
+			d.MarginDB = 10
+		}
+		if d.BaselineSeconds == 0 {
+			d.BaselineSeconds = 60
+		}
+	}
+	if d.Cooldown == 0 {
+		d.Cooldown = 30
+	}
+}
+
+// validate 校验音频检测器
+func (d *AudioDetector) validate() error {
+	if !cameraIDPattern.MatchString(d.Name) {
+		return fmt.Errorf("无效的音频检测器名称 %q: 只允许 1-64 位字母、数字、下划线和短横线", d.Name)
+	}
+	switch d.Type {
+	case AudioDetectThreshold, AudioDetectSilence:
+		if d.ThresholdDBFS < -96 || d.ThresholdDBFS >= 0 {
+			return fmt.Errorf("音频检测器 %s 阈值无效: %g dBFS（-96 到 0）", d.Name, d.ThresholdDBFS)
+		}
+	case AudioDetectSustained:
+		if d.MarginDB <= 0 || d.MarginDB > 60 {
+			return fmt.Errorf("音频检测器 %s 基线余量无效: %g dB（0-60）", d.Name, d.MarginDB)
+		}
+		if d.BaselineSeconds < 5 || d.BaselineSeconds > 3600 {
+			return fmt.Errorf("音频检测器 %s 基线时长无效: %d 秒（5-3600）", d.Name, d.BaselineSeconds)
+		}
+	default:
+		return fmt.Errorf("音频检测器 %s 类型无效: %q（threshold, sustained, silence）", d.Name, d.Type)
+	}
+	if d.MinDurationMs < 20 || d.MinDurationMs > 600000 {
+		return fmt.Errorf("音频检测器 %s 最短时长无效: %d 毫秒（20-600000）", d.Name, d.MinDurationMs)
+	}
+	if d.Cooldown < 0 || d.Cooldown > 86400 {
+		return fmt.Errorf("音频检测器 %s 冷却时间无效: %d 秒", d.Name, d.Cooldown)
+	}
+	return nil
+}
+
+// validateAudioDetectors 校验摄像头的音频检测器
+func validateAudioDetectors(audio AudioConfig) error {
+	if len(audio.Detectors) == 0 {
+		return nil
+	}
+	if !audio.Enabled {
+		return fmt.Errorf("音频检测器需要启用音频")
+	}
+	if len(audio.Detectors) > maxAudioDetectors {
+		return fmt.Errorf("音频检测器过多: %d（最多 %d 个）", len(audio.Detectors), maxAudioDetectors)
+	}
+	names := make(map[string]bool)
+	for i := range audio.Detectors {
+		if err := audio.Detectors[i].validate(); err != nil {
+			return err
+		}
+		if names[audio.Detectors[i].Name] {
+			return fmt.Errorf("音频检测器名称重复: %q", audio.Detectors[i].Name)
+		}
+		names[audio.Detectors[i].Name] = true
+	}
+	return nil
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +20,8 @@ type Config struct {
 	Storage StorageConfig  `yaml:"storage"`
 	Stream  StreamConfig   `yaml:"stream"`
 	Preview PreviewConfig  `yaml:"preview"`
+	// 编码参数档位，摄像头通过 encoders.record/hls/rtmp 按名称引用
+	Encoders EncoderProfiles `yaml:"encoders"`
 }
 
 // ServerConfig 服务器配置
@@ -49,27 +54,89 @@ type WebRTCConfig struct {
 
 // CameraConfig 摄像头配置
 type CameraConfig struct {
-	ID          string      `yaml:"id"`
-	Name        string      `yaml:"name"`
-	Type        string      `yaml:"type"` // usb, rtsp, hls, file
-	DeviceIndex int         `yaml:"device_index"`
-	RTSPUrl     string      `yaml:"rtsp_url"`
-	HLSUrl      string      `yaml:"hls_url"` // HLS/m3u8 流地址
-	Width       int         `yaml:"width"`
-	Height      int         `yaml:"height"`
-	FPS         int         `yaml:"fps"`
-	Enabled     bool        `yaml:"enabled"`
-	Audio       AudioConfig `yaml:"audio"`
+	ID          string      `yaml:"id" json:"id"`
+	Name        string      `yaml:"name" json:"name"`
+	Type        string      `yaml:"type" json:"type"` // usb, rtsp, hls, file, testsrc, mjpeg_http, snapshot_http, push
+	DeviceIndex int         `yaml:"device_index" json:"device_index"`
+	RTSPUrl     string      `yaml:"rtsp_url" json:"rtsp_url"`
+	HLSUrl      string      `yaml:"hls_url" json:"hls_url"`       // HLS/m3u8 流地址
+	FilePath    string      `yaml:"file_path" json:"file_path"`   // 本地视频文件路径（type 为 file 时循环播放）
+	HTTPUrl     string      `yaml:"http_url" json:"http_url"`     // HTTP MJPEG 流或快照地址（type 为 mjpeg_http / snapshot_http）
+	Username    string      `yaml:"username" json:"username"`     // 源认证用户名（HTTP 源、RTSP）
+	Password    string      `yaml:"password" json:"password"`     // 源认证密码，API 输出时隐藏
+	PushToken   string      `yaml:"push_token" json:"push_token"` // 推送令牌（type 为 push 时必填）
+	Width       int         `yaml:"width" json:"width"`
+	Height      int         `yaml:"height" json:"height"`
+	FPS         int         `yaml:"fps" json:"fps"`
+	Enabled     bool        `yaml:"enabled" json:"enabled"`
+	Audio       AudioConfig `yaml:"audio" json:"audio"`
+	// 画面停滞超时（秒），超时无新帧则重启采集，默认 10，负数禁用
+	// push 类型默认禁用：设备可能长时间休眠不推送，需要时显式配置
+	StallTimeout int `yaml:"stall_timeout" json:"stall_timeout"`
+	// 测试图案（type 为 testsrc 时生效）
+	TestPattern TestPatternConfig `yaml:"test_pattern" json:"test_pattern"`
+	// 额外的预览输出档位（由同一 FFmpeg 进程输出），main 档位固定为摄像头分辨率
+	Profiles []ProfileConfig `yaml:"profiles" json:"profiles"`
+	// 录像编码方式（仅 rtsp）: auto（默认，探测源编码，兼容时直接复制）, copy, transcode
+	RecordMode string `yaml:"record_mode" json:"record_mode"`
+	// 各输出使用的编码档位，为空时使用内置的 record/hls/rtmp 档位
+	Encoders CameraEncoders `yaml:"encoders" json:"encoders"`
+	// RTSP 选项（type 为 rtsp 时生效），认证使用 username/password
+	RTSP RTSPConfig `yaml:"rtsp" json:"rtsp"`
+	// 隐私遮挡区域，在所有输出（预览、直播、录像）之前涂黑
+	PrivacyMasks []PrivacyMask `yaml:"privacy_masks" json:"privacy_masks"`
+	// 画面叠加文字（时间戳、名称、自定义文本）
+	OSD OSDConfig `yaml:"osd" json:"osd"`
+	// 画面变换（旋转、翻转、裁剪、去隔行、降噪），仅 FFmpeg 类摄像头
+	Transform TransformConfig `yaml:"transform" json:"transform"`
+	// 隐私模式计划时段，也可通过 POST /api/cameras/:id/privacy 手动切换
+	PrivacySchedules []PrivacySchedule `yaml:"privacy_schedules" json:"privacy_schedules"`
+}
+
+// RTSPConfig RTSP 源选项
+type RTSPConfig struct {
+	Transport    string   `yaml:"transport" json:"transport"`         // tcp（默认）, udp, http, auto（由 FFmpeg 先试 UDP 再试 TCP）
+	Timeout      int      `yaml:"timeout" json:"timeout"`             // 连接和读取超时（秒），默认 10
+	FallbackURLs []string `yaml:"fallback_urls" json:"fallback_urls"` // 备用地址，当前地址无法打开或画面停滞时按顺序切换
+	// 子码流地址（可选）：预览、WebRTC、移动侦测使用子码流，主码流 rtsp_url 只用于录像
+	// 此时 width/height/fps 为预览输出参数，应与子码流一致
+	SubURL string `yaml:"sub_url" json:"sub_url"`
+}
+
+// 录像编码方式
+const (
+	RecordModeAuto      = "auto"      // 启动时用 ffprobe 探测，源编码与容器兼容时复制，否则转码
+	RecordModeCopy      = "copy"      // 不探测，直接复制原始码流
+	RecordModeTranscode = "transcode" // 始终用 libx264 重新编码
+)
+
+// ProfileConfig 预览输出档位，如供手机观看、移动侦测、缩略图使用的低分辨率子码流
+type ProfileConfig struct {
+	Name    string `yaml:"name" json:"name"` // 档位名称，订阅时通过 profile 参数选择
+	Width   int    `yaml:"width" json:"width"`
+	Height  int    `yaml:"height" json:"height"`
+	FPS     int    `yaml:"fps" json:"fps"`         // 默认与摄像头相同
+	Quality int    `yaml:"quality" json:"quality"` // JPEG 质量 1-31，越小越好，默认使用 preview.mjpeg.quality
+}
+
+// TestPatternConfig 合成测试图案配置，分辨率和帧率使用摄像头的 width/height/fps
+type TestPatternConfig struct {
+	Pattern       string `yaml:"pattern" json:"pattern"`               // lavfi 视频源: testsrc2（默认）, testsrc, smptebars
+	Clock         bool   `yaml:"clock" json:"clock"`                   // 是否叠加实时时钟
+	ToneFrequency int    `yaml:"tone_frequency" json:"tone_frequency"` // 启用音频时的正弦波频率 Hz，默认 1000
 }
 
 // AudioConfig 音频配置
 type AudioConfig struct {
-	Enabled     bool   `yaml:"enabled"`
-	Type        string `yaml:"type"`         // usb, pulse, alsa, avfoundation
-	DeviceIndex int    `yaml:"device_index"` // 音频设备索引
-	DeviceName  string `yaml:"device_name"`  // 音频设备名称 (Windows/macOS)
-	SampleRate  int    `yaml:"sample_rate"`  // 采样率，默认 44100
-	Channels    int    `yaml:"channels"`     // 声道数，默认 2
+	Enabled     bool   `yaml:"enabled" json:"enabled"`
+	Type        string `yaml:"type" json:"type"`                 // usb, pulse, alsa, avfoundation
+	DeviceIndex int    `yaml:"device_index" json:"device_index"` // 音频设备索引
+	DeviceName  string `yaml:"device_name" json:"device_name"`   // 音频设备名称 (Windows/macOS；Linux PulseAudio 为 source 名称)
+	SampleRate  int    `yaml:"sample_rate" json:"sample_rate"`   // 采样率，默认 44100
+	Channels    int    `yaml:"channels" json:"channels"`         // 声道数，默认 2
+
+	// 音频事件检测器，事件与画面告警一起进入告警列表
+	Detectors []AudioDetector `yaml:"detectors" json:"detectors"`
 }
 
 // StorageConfig 存储配置
@@ -103,6 +170,21 @@ func Load(path string) (*Config, error) {
 	// 设置默认值
 	setDefaults(&config)
 
+	// 校验编码档位及摄像头对档位的引用
+	if err := config.Encoders.Validate(); err != nil {
+		return nil, err
+	}
+	// 摄像头与 API 使用相同的校验规则
+	for i := range config.Cameras {
+		cam := &config.Cameras[i]
+		if err := cam.Validate(); err != nil {
+			return nil, fmt.Errorf("摄像头 %s 配置无效: %w", cam.ID, err)
+		}
+		if err := config.Encoders.ValidateCamera(*cam); err != nil {
+			return nil, err
+		}
+	}
+
 	return &config, nil
 }
 
@@ -140,6 +222,225 @@ func (c *StorageConfig) GetSegmentDurationSeconds() int {
 	return seconds
 }
 
+// SetCameraDefaults 设置摄像头默认值
+func SetCameraDefaults(cam *CameraConfig) {
+	// 推送型摄像头由设备决定推送间隔，未配置时保持 0 禁用看门狗
+	if cam.StallTimeout == 0 && cam.Type != "push" {
+		cam.StallTimeout = 10
+	}
+	if cam.TestPattern.ToneFrequency == 0 {
+		cam.TestPattern.ToneFrequency = 1000
+	}
+	if cam.RecordMode == "" {
+		cam.RecordMode = RecordModeAuto
+	}
+	if cam.Type == "rtsp" {
+		if cam.RTSP.Transport == "" {
+			cam.RTSP.Transport = "tcp"
+		}
+		if cam.RTSP.Timeout == 0 {
+			cam.RTSP.Timeout = 10
+		}
+	}
+
+	if cam.OSD.Enabled {
+		setOSDDefaults(&cam.OSD)
+	}
+
+	for i := range cam.Profiles {
+		if cam.Profiles[i].FPS == 0 {
+			cam.Profiles[i].FPS = cam.FPS
+		}
+	}
+
+	// 音频默认值
+	if cam.Audio.SampleRate == 0 {
+		cam.Audio.SampleRate = 44100
+	}
+	if cam.Audio.Channels == 0 {
+		cam.Audio.Channels = 2
+	}
+	for i := range cam.Audio.Detectors {
+		setAudioDetectorDefaults(&cam.Audio.Detectors[i])
+	}
+}
+
+// WithCameraDefaults 返回设置了默认值的副本，cam 本身（含切片元素）保持不变
+// 用于交给采集器的配置；写入配置文件的仍是用户提交的原样配置
+func WithCameraDefaults(cam CameraConfig) CameraConfig {
+	cam.Profiles = slices.Clone(cam.Profiles)
+	cam.Audio.Detectors = slices.Clone(cam.Audio.Detectors)
+	SetCameraDefaults(&cam)
+	return cam
+}
+
+// cameraIDPattern 摄像头 ID 会用作目录名和 URL 路径，只允许安全字符
+var cameraIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Validate 校验摄像头配置
+func (c *CameraConfig) Validate() error {
+	if !cameraIDPattern.MatchString(c.ID) {
+		return fmt.Errorf("无效的摄像头 ID %q: 只允许 1-64 位字母、数字、下划线和短横线", c.ID)
+	}
+	if c.Name == "" {
+		return fmt.Errorf("摄像头名称不能为空")
+	}
+	if c.FPS <= 0 || c.FPS > 120 {
+		return fmt.Errorf("无效的帧率: %d", c.FPS)
+	}
+	if c.Width < 0 || c.Height < 0 {
+		return fmt.Errorf("无效的分辨率: %dx%d", c.Width, c.Height)
+	}
+
+	// FFmpeg 采集的来源按配置的分辨率输出预览帧
+	needSize := true
+	switch c.Type {
+	case "", "usb":
+		if c.DeviceIndex < 0 {
+			return fmt.Errorf("无效的设备索引: %d", c.DeviceIndex)
+		}
+	case "rtsp":
+		if c.RTSPUrl == "" {
+			return fmt.Errorf("rtsp 类型需要 rtsp_url")
+		}
+		if err := c.RTSP.validate(c.RTSPUrl); err != nil {
+			return err
+		}
+	case "hls":
+		if c.HLSUrl == "" {
+			return fmt.Errorf("hls 类型需要 hls_url")
+		}
+	case "file":
+		if c.FilePath == "" {
+			return fmt.Errorf("file 类型需要 file_path")
+		}
+	case "testsrc":
+	case "mjpeg_http", "snapshot_http":
+		if c.HTTPUrl == "" {
+			return fmt.Errorf("%s 类型需要 http_url", c.Type)
+		}
+		needSize = false
+	case "push":
+		if c.PushToken == "" {
+			return fmt.Errorf("push 类型需要 push_token")
+		}
+		needSize = false
+	default:
+		return fmt.Errorf("未知的摄像头类型: %q", c.Type)
+	}
+	if needSize && (c.Width == 0 || c.Height == 0) {
+		return fmt.Errorf("%s 类型需要 width 和 height", c.Type)
+	}
+
+	if c.Audio.Enabled && (c.Audio.SampleRate <= 0 || c.Audio.Channels <= 0) {
+		return fmt.Errorf("无效的音频参数: 采样率 %d, 声道数 %d", c.Audio.SampleRate, c.Audio.Channels)
+	}
+	if err := validateAudioDetectors(c.Audio); err != nil {
+		return err
+	}
+
+	switch c.RecordMode {
+	case "", RecordModeAuto, RecordModeTranscode:
+	case RecordModeCopy:
+		if c.Type != "rtsp" {
+			return fmt.Errorf("record_mode copy 仅支持 rtsp 类型")
+		}
+		if len(c.PrivacyMasks) > 0 {
+			return fmt.Errorf("record_mode copy 不能与隐私遮挡同时使用，遮挡需要重新编码")
+		}
+		if c.OSD.Enabled {
+			return fmt.Errorf("record_mode copy 不能与 OSD 同时使用，叠加文字需要重新编码")
+		}
+		if c.Transform.Enabled() {
+			return fmt.Errorf("record_mode copy 不能与画面变换同时使用，变换需要重新编码")
+		}
+	default:
+		return fmt.Errorf("无效的录像编码方式: %q（auto, copy, transcode）", c.RecordMode)
+	}
+
+	if err := ValidatePrivacyMasks(c.PrivacyMasks); err != nil {
+		return err
+	}
+	if c.OSD.Enabled && !needSize && c.Type != "push" {
+		return fmt.Errorf("%s 类型不支持 OSD", c.Type)
+	}
+	if err := c.OSD.validate(); err != nil {
+		return err
+	}
+	if err := c.Transform.validate(); err != nil {
+		return err
+	}
+	for i := range c.PrivacySchedules {
+		if err := c.PrivacySchedules[i].validate(); err != nil {
+			return err
+		}
+	}
+	if c.Transform.Enabled() && !needSize {
+		return fmt.Errorf("%s 类型不支持画面变换", c.Type)
+	}
+	if len(c.Audio.Detectors) > 0 && !needSize {
+		return fmt.Errorf("%s 类型没有音频，不支持音频检测器", c.Type)
+	}
+
+	if len(c.Profiles) > 0 && !needSize {
+		return fmt.Errorf("%s 类型不支持多档位预览", c.Type)
+	}
+	names := map[string]bool{MainProfile: true}
+	for _, p := range c.Profiles {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		if names[p.Name] {
+			return fmt.Errorf("预览档位名称重复或保留: %q", p.Name)
+		}
+		names[p.Name] = true
+	}
+	return nil
+}
+
+// validate 校验 RTSP 选项，primary 为主地址
+func (r *RTSPConfig) validate(primary string) error {
+	switch r.Transport {
+	case "", "tcp", "udp", "http", "auto":
+	default:
+		return fmt.Errorf("无效的 RTSP 传输方式: %q（tcp, udp, http, auto）", r.Transport)
+	}
+	if r.Timeout < 0 || r.Timeout > 300 {
+		return fmt.Errorf("无效的 RTSP 超时: %d 秒", r.Timeout)
+	}
+	urls := append([]string{primary}, r.FallbackURLs...)
+	if r.SubURL != "" {
+		urls = append(urls, r.SubURL)
+	}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "rtsp" && u.Scheme != "rtsps") || u.Host == "" {
+			return fmt.Errorf("无效的 RTSP 地址: %s", MaskURL(raw))
+		}
+	}
+	return nil
+}
+
+// MainProfile 主预览档位名称（摄像头配置的分辨率和帧率）
+const MainProfile = "main"
+
+// validate 校验预览档位
+func (p *ProfileConfig) validate() error {
+	if !cameraIDPattern.MatchString(p.Name) {
+		return fmt.Errorf("无效的预览档位名称 %q", p.Name)
+	}
+	if p.Width <= 0 || p.Height <= 0 {
+		return fmt.Errorf("预览档位 %s 需要 width 和 height", p.Name)
+	}
+	if p.FPS <= 0 || p.FPS > 120 {
+		return fmt.Errorf("预览档位 %s 帧率无效: %d", p.Name, p.FPS)
+	}
+	if p.Quality < 0 || p.Quality > 31 {
+		return fmt.Errorf("预览档位 %s 质量无效: %d（1-31）", p.Name, p.Quality)
+	}
+	return nil
+}
+
 // setDefaults 设置默认值
 func setDefaults(config *Config) {
 	if config.Server.Host == "" {
@@ -170,14 +471,11 @@ func setDefaults(config *Config) {
 		config.Stream.TempPath = "./temp"
 	}
 
-	// 音频默认值
 	for i := range config.Cameras {
-		if config.Cameras[i].Audio.SampleRate == 0 {
-			config.Cameras[i].Audio.SampleRate = 44100
-		}
-		if config.Cameras[i].Audio.Channels == 0 {
-			config.Cameras[i].Audio.Channels = 2
-		}
+		SetCameraDefaults(&config.Cameras[i])
+	}
+	for i := range config.Encoders {
+		setEncoderDefaults(&config.Encoders[i])
 	}
 
 	// 预览默认值
@@ -0,0 +1,50 @@
+package config
+
+import "testing"
+
+func TestSetCameraDefaultsStallTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		cameraType string
+		configured int
+		want       int
+	}{
+		{"usb 默认", "usb", 0, 10},
+		{"rtsp 默认", "rtsp", 0, 10},
+		{"push 默认禁用", "push", 0, 0},
+		{"push 显式配置", "push", 60, 60},
+		{"显式禁用", "usb", -1, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cam := CameraConfig{Type: tt.cameraType, StallTimeout: tt.configured}
+			SetCameraDefaults(&cam)
+			if cam.StallTimeout != tt.want {
+				t.Errorf("StallTimeout = %d, want %d", cam.StallTimeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithCameraDefaultsKeepsSubmitted(t *testing.T) {
+	cam := CameraConfig{
+		Type:     "usb",
+		FPS:      15,
+		Profiles: []ProfileConfig{{Name: "sub", Width: 640, Height: 360}},
+		Audio:    AudioConfig{Detectors: []AudioDetector{{Name: "loud", Type: AudioDetectThreshold}}},
+	}
+
+	defaulted := WithCameraDefaults(cam)
+	if defaulted.StallTimeout != 10 || defaulted.Profiles[0].FPS != 15 {
+		t.Fatalf("未设置默认值: stall_timeout=%d profile fps=%d", defaulted.StallTimeout, defaulted.Profiles[0].FPS)
+	}
+	if cam.StallTimeout != 0 || cam.RecordMode != "" || cam.Audio.SampleRate != 0 {
+		t.Fatalf("原配置被修改: %+v", cam)
+	}
+	if cam.Profiles[0].FPS != 0 {
+		t.Fatalf("原配置的档位被修改: fps=%d", cam.Profiles[0].FPS)
+	}
+	if cam.Audio.Detectors[0] != (AudioDetector{Name: "loud", Type: AudioDetectThreshold}) {
+		t.Fatalf("原配置的检测器被修改: %+v", cam.Audio.Detectors[0])
+	}
+}
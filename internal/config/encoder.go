@@ -0,0 +1,264 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+)
+
+// 内置编码档位名称，也是各输出未指定档位时的默认值
+// 在 encoders 中定义同名档位即可覆盖内置参数
+const (
+	EncoderRecord = "record" // 分段录像
+	EncoderHLS    = "hls"    // HLS 输出
+	EncoderRTMP   = "rtmp"   // RTMP 推流
+)
+
+// EncoderProfile 编码参数档位，录像和直播输出按名称引用
+type EncoderProfile struct {
+	Name         string  `yaml:"name" json:"name"`
+	Codec        string  `yaml:"codec" json:"codec"`                 // 视频编码器，默认 libx264，也可用 h264_v4l2m2m 等硬件编码器
+	Preset       string  `yaml:"preset" json:"preset"`               // 编码速度预设，libx264/libx265 默认 ultrafast
+	Tune         string  `yaml:"tune" json:"tune"`                   // 可选，如 zerolatency
+	CRF          int     `yaml:"crf" json:"crf"`                     // 恒定质量 1-51，与 bitrate 二选一，都未设置时为 23
+	Bitrate      string  `yaml:"bitrate" json:"bitrate"`             // 目标码率，如 "1500k"
+	MaxRate      string  `yaml:"maxrate" json:"maxrate"`             // 峰值码率，可选
+	BufSize      string  `yaml:"bufsize" json:"bufsize"`             // 码率控制缓冲区，默认与 maxrate 相同
+	GOPSeconds   float64 `yaml:"gop_seconds" json:"gop_seconds"`     // 关键帧间隔（秒），按摄像头帧率换算为帧数，默认 2
+	FixedGOP     bool    `yaml:"fixed_gop" json:"fixed_gop"`         // 固定关键帧间隔（-keyint_min 等于 GOP 并关闭场景切换插入关键帧），直播分片更均匀
+	Profile      string  `yaml:"profile" json:"profile"`             // H.264 profile，如 baseline, main, high
+	Level        string  `yaml:"level" json:"level"`                 // H.264 level，如 3.1
+	AudioBitrate string  `yaml:"audio_bitrate" json:"audio_bitrate"` // AAC 码率，默认 128k
+}
+
+// EncoderProfiles 已配置的编码档位
+type EncoderProfiles []EncoderProfile
+
+// CameraEncoders 摄像头各输出使用的编码档位名称，为空时使用同名内置档位
+type CameraEncoders struct {
+	Record string `yaml:"record" json:"record"`
+	HLS    string `yaml:"hls" json:"hls"`
+	RTMP   string `yaml:"rtmp" json:"rtmp"`
+}
+
+// builtinEncoders 内置编码档位，码率、预设等与此前写死的参数一致，关键帧参数有以下变化：
+//   - 录像的 -g 由固定 60 帧改为按帧率换算的 2 秒（30fps 时相同）
+//   - HLS、RTMP 的 fixed_gop 统一输出 -keyint_min <gop> -sc_threshold 0；此前 HLS 没有 -keyint_min，
+//     RTMP 为 -keyint_min <fps>（GOP 的一半）。关闭场景切换后关键帧只按 -g 插入，实际关键帧间隔不变
+var builtinEncoders = map[string]EncoderProfile{
+	EncoderRecord: {
+		Name:         EncoderRecord,
+		Codec:        "libx264",
+		Preset:       "ultrafast",
+		CRF:          23,
+		GOPSeconds:   2,
+		AudioBitrate: "128k",
+	},
+	EncoderHLS: {
+		Name:         EncoderHLS,
+		Codec:        "libx264",
+		Preset:       "ultrafast",
+		Tune:         "zerolatency",
+		Bitrate:      "1500k",
+		MaxRate:      "2000k",
+		BufSize:      "3000k",
+		GOPSeconds:   2,
+		FixedGOP:     true,
+		Profile:      "baseline",
+		Level:        "3.1",
+		AudioBitrate: "128k",
+	},
+	EncoderRTMP: {
+		Name:         EncoderRTMP,
+		Codec:        "libx264",
+		Preset:       "ultrafast",
+		Tune:         "zerolatency",
+		Bitrate:      "2000k",
+		MaxRate:      "2500k",
+		BufSize:      "4000k",
+		GOPSeconds:   2,
+		FixedGOP:     true,
+		Profile:      "baseline",
+		Level:        "3.1",
+		AudioBitrate: "128k",
+	},
+}
+
+var (
+	// bitratePattern 码率，如 800k、1.5M、2000000
+	bitratePattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?[kKmM]?$`)
+	// codecPattern FFmpeg 编码器名称
+	codecPattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+	// levelPattern H.264/H.265 level，如 3.1、41
+	levelPattern = regexp.MustCompile(`^[0-9](\.[0-9])?$|^[0-9]{2}$`)
+
+	// x264/x265 支持的预设
+	x26xPresets = []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow", "placebo"}
+)
+
+// isX26x 是否为支持 preset/crf 的软件编码器
+func isX26x(codec string) bool {
+	return codec == "libx264" || codec == "libx265"
+}
+
+// Get 按名称查找编码档位，配置中的档位优先于内置档位
+func (e EncoderProfiles) Get(name string) (EncoderProfile, bool) {
+	for _, p := range e {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	p, ok := builtinEncoders[name]
+	return p, ok
+}
+
+// Resolve 获取某个输出使用的编码档位，name 为空或不存在时使用 output 对应的内置档位
+func (e EncoderProfiles) Resolve(name, output string) EncoderProfile {
+	if name == "" {
+		name = output
+	}
+	if p, ok := e.Get(name); ok {
+		return p
+	}
+	p, _ := e.Get(output)
+	return p
+}
+
+// Validate 校验所有编码档位
+func (e EncoderProfiles) Validate() error {
+	names := make(map[string]bool)
+	for i := range e {
+		if err := e[i].validate(); err != nil {
+			return err
+		}
+		if names[e[i].Name] {
+			return fmt.Errorf("编码档位名称重复: %q", e[i].Name)
+		}
+		names[e[i].Name] = true
+	}
+	return nil
+}
+
+// ValidateCamera 校验摄像头引用的编码档位是否存在
+func (e EncoderProfiles) ValidateCamera(cam CameraConfig) error {
+	for _, name := range []string{cam.Encoders.Record, cam.Encoders.HLS, cam.Encoders.RTMP} {
+		if name == "" {
+			continue
+		}
+		if _, ok := e.Get(name); !ok {
+			return fmt.Errorf("摄像头 %s 引用的编码档位不存在: %q", cam.ID, name)
+		}
+	}
+	return nil
+}
+
+// setEncoderDefaults 设置编码档位默认值
+func setEncoderDefaults(p *EncoderProfile) {
+	if p.Codec == "" {
+		p.Codec = "libx264"
+	}
+	if p.Preset == "" && isX26x(p.Codec) {
+		p.Preset = "ultrafast"
+	}
+	if p.CRF == 0 && p.Bitrate == "" && isX26x(p.Codec) {
+		p.CRF = 23
+	}
+	if p.GOPSeconds == 0 {
+		p.GOPSeconds = 2
+	}
+	if p.AudioBitrate == "" {
+		p.AudioBitrate = "128k"
+	}
+}
+
+// validate 校验编码档位
+func (p *EncoderProfile) validate() error {
+	if !cameraIDPattern.MatchString(p.Name) {
+		return fmt.Errorf("无效的编码档位名称 %q: 只允许 1-64 位字母、数字、下划线和短横线", p.Name)
+	}
+	if !codecPattern.MatchString(p.Codec) {
+		return fmt.Errorf("编码档位 %s 编码器无效: %q", p.Name, p.Codec)
+	}
+	if p.Preset != "" && isX26x(p.Codec) && !slices.Contains(x26xPresets, p.Preset) {
+		return fmt.Errorf("编码档位 %s 预设无效: %q", p.Name, p.Preset)
+	}
+
+	switch {
+	case p.CRF != 0 && p.Bitrate != "":
+		return fmt.Errorf("编码档位 %s 的 crf 和 bitrate 只能设置一个", p.Name)
+	case p.CRF != 0 && !isX26x(p.Codec):
+		return fmt.Errorf("编码档位 %s: %s 不支持 crf，请设置 bitrate", p.Name, p.Codec)
+	case p.CRF < 0 || p.CRF > 51:
+		return fmt.Errorf("编码档位 %s crf 无效: %d（1-51）", p.Name, p.CRF)
+	case p.CRF == 0 && p.Bitrate == "":
+		return fmt.Errorf("编码档位 %s 需要 crf 或 bitrate", p.Name)
+	}
+
+	for _, rate := range []struct{ key, value string }{
+		{"bitrate", p.Bitrate},
+		{"maxrate", p.MaxRate},
+		{"bufsize", p.BufSize},
+		{"audio_bitrate", p.AudioBitrate},
+	} {
+		if rate.value != "" && !bitratePattern.MatchString(rate.value) {
+			return fmt.Errorf("编码档位 %s %s 无效: %q（如 1500k、2M）", p.Name, rate.key, rate.value)
+		}
+	}
+
+	if p.GOPSeconds <= 0 || p.GOPSeconds > 20 {
+		return fmt.Errorf("编码档位 %s 关键帧间隔无效: %g 秒（0-20）", p.Name, p.GOPSeconds)
+	}
+	if p.Level != "" && !levelPattern.MatchString(p.Level) {
+		return fmt.Errorf("编码档位 %s level 无效: %q", p.Name, p.Level)
+	}
+	return nil
+}
+
+// GOPFrames 按帧率换算关键帧间隔帧数
+func (p *EncoderProfile) GOPFrames(fps int) int {
+	return max(1, int(math.Round(p.GOPSeconds*float64(fps))))
+}
+
+// VideoArgs 生成 FFmpeg 视频编码参数
+func (p *EncoderProfile) VideoArgs(fps int) []string {
+	args := []string{"-c:v", p.Codec}
+	if p.Preset != "" {
+		args = append(args, "-preset", p.Preset)
+	}
+	if p.Tune != "" {
+		args = append(args, "-tune", p.Tune)
+	}
+	if p.Profile != "" {
+		args = append(args, "-profile:v", p.Profile)
+	}
+	if p.Level != "" {
+		args = append(args, "-level", p.Level)
+	}
+
+	if p.Bitrate != "" {
+		args = append(args, "-b:v", p.Bitrate)
+	} else {
+		args = append(args, "-crf", fmt.Sprintf("%d", p.CRF))
+	}
+	if p.MaxRate != "" {
+		bufSize := p.BufSize
+		if bufSize == "" {
+			bufSize = p.MaxRate
+		}
+		args = append(args, "-maxrate", p.MaxRate, "-bufsize", bufSize)
+	}
+
+	gop := fmt.Sprintf("%d", p.GOPFrames(fps))
+	args = append(args, "-g", gop)
+	if p.FixedGOP {
+		args = append(args, "-keyint_min", gop, "-sc_threshold", "0")
+	}
+
+	return append(args, "-pix_fmt", "yuv420p")
+}
+
+// AudioArgs 生成 FFmpeg 音频编码参数（AAC）
+func (p *EncoderProfile) AudioArgs() []string {
+	return []string{"-c:a", "aac", "-b:a", p.AudioBitrate}
+}
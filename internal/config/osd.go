@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// OSD 位置
+const (
+	OSDTopLeft     = "top-left"
+	OSDTopRight    = "top-right"
+	OSDBottomLeft  = "bottom-left"
+	OSDBottomRight = "bottom-right"
+)
+
+// OSDConfig 画面叠加文字（时间、摄像头名称、自定义文本），由 FFmpeg drawtext 渲染
+// FFmpeg 类摄像头叠加到预览（含 WebRTC、HLS、RTMP）和录像，推送型摄像头只叠加到录像
+type OSDConfig struct {
+	Enabled    bool   `yaml:"enabled" json:"enabled"`
+	Position   string `yaml:"position" json:"position"`       // top-left（默认）, top-right, bottom-left, bottom-right
+	FontSize   int    `yaml:"font_size" json:"font_size"`     // 字号（像素），默认 24
+	FontFile   string `yaml:"font_file" json:"font_file"`     // 字体文件，中文名称需指定 CJK 字体，为空使用 fontconfig 默认字体
+	FontColor  string `yaml:"font_color" json:"font_color"`   // 文字颜色，默认 white
+	TimeFormat string `yaml:"time_format" json:"time_format"` // 时间格式（strftime），默认 "%Y-%m-%d %H:%M:%S"
+	ShowName   bool   `yaml:"show_name" json:"show_name"`     // 时间前显示摄像头名称
+	Text       string `yaml:"text" json:"text"`               // 固定文本，显示在第二行
+	// 只叠加到录像，预览和直播输出保持原画面
+	RecordingOnly bool `yaml:"recording_only" json:"recording_only"`
+}
+
+// OSD 动态文本（API 设置，如 "ARMED"）的最大长度
+const MaxOSDTextLength = 128
+
+// fontColorPattern FFmpeg 颜色，如 white、#FFFFFF、yellow@0.8
+var fontColorPattern = regexp.MustCompile(`^[A-Za-z0-9#@.]{1,32}$`)
+
+// setOSDDefaults 设置 OSD 默认值
+func setOSDDefaults(o *OSDConfig) {
+	if o.Position == "" {
+		o.Position = OSDTopLeft
+	}
+	if o.FontSize == 0 {
+		o.FontSize = 24
+	}
+	if o.FontColor == "" {
+		o.FontColor = "white"
+	}
+	if o.TimeFormat == "" {
+		o.TimeFormat = "%Y-%m-%d %H:%M:%S"
+	}
+}
+
+// validate 校验 OSD 配置
+func (o *OSDConfig) validate() error {
+	if !o.Enabled {
+		return nil
+	}
+	switch o.Position {
+	case OSDTopLeft, OSDTopRight, OSDBottomLeft, OSDBottomRight:
+	default:
+		return fmt.Errorf("无效的 OSD 位置: %q（top-left, top-right, bottom-left, bottom-right）", o.Position)
+	}
+	if o.FontSize < 8 || o.FontSize > 200 {
+		return fmt.Errorf("无效的 OSD 字号: %d（8-200）", o.FontSize)
+	}
+	if strings.ContainsAny(o.FontFile, "'\n") {
+		return fmt.Errorf("OSD 字体文件路径不能包含单引号或换行")
+	}
+	if !fontColorPattern.MatchString(o.FontColor) {
+		return fmt.Errorf("无效的 OSD 文字颜色: %q", o.FontColor)
+	}
+	if len(o.Text) > MaxOSDTextLength {
+		return fmt.Errorf("OSD 固定文本过长（最多 %d 字节）", MaxOSDTextLength)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// 隐私遮挡数量限制
+const (
+	maxPrivacyMasks  = 16
+	maxPrivacyPoints = 64
+)
+
+// PrivacyMask 隐私遮挡区域，坐标按画面宽高归一化到 0-1，左上角为原点
+// 遮挡区域在采集管线中涂黑，预览、WebRTC、HLS、RTMP 和录像都不包含该区域
+type PrivacyMask struct {
+	Name   string       `yaml:"name" json:"name"`     // 可选，便于识别，如 "邻居窗户"
+	Points [][2]float64 `yaml:"points" json:"points"` // 多边形顶点 [x, y]，至少 3 个
+}
+
+// ValidatePrivacyMasks 校验隐私遮挡区域
+func ValidatePrivacyMasks(masks []PrivacyMask) error {
+	if len(masks) > maxPrivacyMasks {
+		return fmt.Errorf("隐私遮挡区域过多: %d（最多 %d）", len(masks), maxPrivacyMasks)
+	}
+	for i, m := range masks {
+		name := m.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if len(m.Points) < 3 || len(m.Points) > maxPrivacyPoints {
+			return fmt.Errorf("隐私遮挡区域 %s 顶点数无效: %d（3-%d）", name, len(m.Points), maxPrivacyPoints)
+		}
+		for _, p := range m.Points {
+			for _, v := range p {
+				if math.IsNaN(v) || v < 0 || v > 1 {
+					return fmt.Errorf("隐私遮挡区域 %s 坐标无效: [%g, %g]（归一化到 0-1）", name, p[0], p[1])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// PrivacySchedule 隐私模式计划时段（本地时间），处于时段内的摄像头停止采集和录像，直播输出显示占位画面
+// end 早于 start 表示跨午夜（如 22:00-07:00），start 等于 end 表示全天
+type PrivacySchedule struct {
+	Days  []string `yaml:"days" json:"days"`   // mon, tue, wed, thu, fri, sat, sun，为空表示每天
+	Start string   `yaml:"start" json:"start"` // HH:MM
+	End   string   `yaml:"end" json:"end"`     // HH:MM
+}
+
+// weekdayNames 星期名称
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseClock 解析 HH:MM 为当天的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("无效的时间 %q（HH:MM）", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validate 校验计划时段
+func (s *PrivacySchedule) validate() error {
+	for _, day := range s.Days {
+		if _, ok := weekdayNames[day]; !ok {
+			return fmt.Errorf("隐私模式计划星期无效: %q（mon, tue, wed, thu, fri, sat, sun）", day)
+		}
+	}
+	if _, err := parseClock(s.Start); err != nil {
+		return fmt.Errorf("隐私模式计划开始时间: %w", err)
+	}
+	if _, err := parseClock(s.End); err != nil {
+		return fmt.Errorf("隐私模式计划结束时间: %w", err)
+	}
+	return nil
+}
+
+// onDay 计划是否包含星期 day
+func (s *PrivacySchedule) onDay(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, name := range s.Days {
+		if weekdayNames[name] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// Active 时刻 t 是否处于计划时段，跨午夜的时段按开始当天的星期判断
+func (s *PrivacySchedule) Active(t time.Time) bool {
+	start, err := parseClock(s.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(s.End)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	today := t.Weekday()
+	yesterday := (today + 6) % 7
+
+	switch {
+	case start == end:
+		return s.onDay(today)
+	case start < end:
+		return s.onDay(today) && now >= start && now < end
+	default:
+		return (s.onDay(today) && now >= start) || (s.onDay(yesterday) && now < end)
+	}
+}
+
+// PrivacyScheduled 时刻 t 是否处于任一计划时段
+func PrivacyScheduled(schedules []PrivacySchedule, t time.Time) bool {
+	for i := range schedules {
+		if schedules[i].Active(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// at 2026-10-12 是星期一，day 为相对周一的偏移（0=周一 ... 6=周日）
+func at(day int, clock string) time.Time {
+	t, err := time.ParseInLocation("15:04", clock, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return time.Date(2026, 10, 12+day, t.Hour(), t.Minute(), 0, 0, time.Local)
+}
+
+func TestPrivacyScheduleActive(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule PrivacySchedule
+		time     time.Time
+		want     bool
+	}{
+		// 当天时段 [start, end)
+		{"同日时段内", PrivacySchedule{Start: "08:00", End: "18:00"}, at(0, "12:00"), true},
+		{"同日开始时刻", PrivacySchedule{Start: "08:00", End: "18:00"}, at(0, "08:00"), true},
+		{"同日结束时刻", PrivacySchedule{Start: "08:00", End: "18:00"}, at(0, "18:00"), false},
+		{"同日时段前", PrivacySchedule{Start: "08:00", End: "18:00"}, at(0, "07:59"), false},
+		{"同日星期不符", PrivacySchedule{Days: []string{"mon"}, Start: "08:00", End: "18:00"}, at(1, "12:00"), false},
+		{"同日星期相符", PrivacySchedule{Days: []string{"tue", "wed"}, Start: "08:00", End: "18:00"}, at(1, "12:00"), true},
+
+		// 跨午夜
+		{"跨午夜开始当晚", PrivacySchedule{Start: "22:00", End: "07:00"}, at(0, "23:30"), true},
+		{"跨午夜次日凌晨", PrivacySchedule{Start: "22:00", End: "07:00"}, at(1, "06:59"), true},
+		{"跨午夜次日结束时刻", PrivacySchedule{Start: "22:00", End: "07:00"}, at(1, "07:00"), false},
+		{"跨午夜白天", PrivacySchedule{Start: "22:00", End: "07:00"}, at(0, "12:00"), false},
+
+		// 全天
+		{"全天", PrivacySchedule{Start: "00:00", End: "00:00"}, at(3, "15:00"), true},
+		{"全天星期不符", PrivacySchedule{Days: []string{"sat", "sun"}, Start: "09:00", End: "09:00"}, at(4, "15:00"), false},
+		{"全天星期相符", PrivacySchedule{Days: []string{"sat", "sun"}, Start: "09:00", End: "09:00"}, at(5, "08:00"), true},
+
+		// 跨午夜按开始当天的星期判断，含周日到周一的回绕
+		{"周五夜间开始", PrivacySchedule{Days: []string{"fri"}, Start: "22:00", End: "07:00"}, at(4, "23:00"), true},
+		{"周六凌晨属于周五时段", PrivacySchedule{Days: []string{"fri"}, Start: "22:00", End: "07:00"}, at(5, "03:00"), true},
+		{"周五凌晨不属于周五时段", PrivacySchedule{Days: []string{"fri"}, Start: "22:00", End: "07:00"}, at(4, "03:00"), false},
+		{"周一凌晨属于周日时段", PrivacySchedule{Days: []string{"sun"}, Start: "22:00", End: "07:00"}, at(7, "03:00"), true},
+		{"周日凌晨不属于周日时段", PrivacySchedule{Days: []string{"sun"}, Start: "22:00", End: "07:00"}, at(6, "03:00"), false},
+
+		// 无法解析的时段（加载配置时已拒绝）
+		{"无效时间", PrivacySchedule{Start: "25:00", End: "07:00"}, at(0, "23:00"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Active(tt.time); got != tt.want {
+				t.Errorf("Active(%s) = %v, want %v", tt.time.Format("Mon 15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrivacyScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule PrivacySchedule
+		wantErr  bool
+	}{
+		{"有效", PrivacySchedule{Days: []string{"mon"}, Start: "22:00", End: "07:00"}, false},
+		{"开始时间无效", PrivacySchedule{Start: "8:0x", End: "07:00"}, true},
+		{"结束时间为空", PrivacySchedule{Start: "08:00"}, true},
+		{"星期无效", PrivacySchedule{Days: []string{"monday"}, Start: "08:00", End: "09:00"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schedule.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidPrivacySchedule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `
+cameras:
+  - id: cam1
+    name: cam1
+    type: testsrc
+    width: 640
+    height: 360
+    fps: 10
+    privacy_schedules:
+      - start: "22:00"
+        end: "7:00am"
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "cam1") {
+		t.Fatalf("Load() error = %v, want error naming cam1", err)
+	}
+}
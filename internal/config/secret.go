@@ -0,0 +1,101 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SecretMask API 输出中替代密码、令牌的占位符
+const SecretMask = "******"
+
+// Masked 返回隐藏密码、推送令牌和 URL 中凭据后的副本，用于 API 输出
+func (c CameraConfig) Masked() CameraConfig {
+	if c.Password != "" {
+		c.Password = SecretMask
+	}
+	if c.PushToken != "" {
+		c.PushToken = SecretMask
+	}
+	c.RTSPUrl = MaskURL(c.RTSPUrl)
+	c.HLSUrl = MaskURL(c.HLSUrl)
+	c.HTTPUrl = MaskURL(c.HTTPUrl)
+	c.RTSP.SubURL = MaskURL(c.RTSP.SubURL)
+	if c.RTSP.FallbackURLs != nil {
+		fallbacks := make([]string, len(c.RTSP.FallbackURLs))
+		for i, raw := range c.RTSP.FallbackURLs {
+			fallbacks[i] = MaskURL(raw)
+		}
+		c.RTSP.FallbackURLs = fallbacks
+	}
+	return c
+}
+
+// RestoreSecrets 将提交中仍为占位符（来自 Masked 输出）的密码、令牌和 URL 还原为 existing 中的值
+func (c *CameraConfig) RestoreSecrets(existing CameraConfig) {
+	if c.Password == SecretMask {
+		c.Password = existing.Password
+	}
+	if c.PushToken == SecretMask {
+		c.PushToken = existing.PushToken
+	}
+	c.RTSPUrl = restoreURL(c.RTSPUrl, existing.RTSPUrl)
+	c.HLSUrl = restoreURL(c.HLSUrl, existing.HLSUrl)
+	c.HTTPUrl = restoreURL(c.HTTPUrl, existing.HTTPUrl)
+	c.RTSP.SubURL = restoreURL(c.RTSP.SubURL, existing.RTSP.SubURL)
+	for i, raw := range c.RTSP.FallbackURLs {
+		for _, old := range existing.RTSP.FallbackURLs {
+			if raw == MaskURL(old) {
+				c.RTSP.FallbackURLs[i] = old
+				break
+			}
+		}
+	}
+}
+
+// restoreURL 提交的地址与原地址隐藏凭据后相同时还原为原地址
+func restoreURL(submitted, existing string) string {
+	if submitted != existing && submitted == MaskURL(existing) {
+		return existing
+	}
+	return submitted
+}
+
+// MaskURL 隐藏 URL 中的密码，无法解析或不含密码时原样返回
+func MaskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		// 无法解析时不确定凭据位置，含 @ 的整体隐藏
+		if strings.Contains(raw, "@") {
+			return SecretMask
+		}
+		return raw
+	}
+	if u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); !ok {
+		return raw
+	}
+	// 直接拼接占位符，避免 * 被转义
+	user := url.User(u.User.Username()).String()
+	u.User = nil
+	prefix := u.Scheme + "://"
+	return prefix + user + ":" + SecretMask + "@" + strings.TrimPrefix(u.String(), prefix)
+}
+
+// WithCredentials 将用户名和密码写入 URL，username 为空时原样返回
+func WithCredentials(raw, username, password string) string {
+	if username == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	if password == "" {
+		u.User = url.User(username)
+	} else {
+		u.User = url.UserPassword(username, password)
+	}
+	return u.String()
+}
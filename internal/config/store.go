@@ -0,0 +1,336 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 摄像头增删改错误
+var (
+	ErrCameraExists   = errors.New("摄像头已存在")
+	ErrCameraNotFound = errors.New("摄像头不存在")
+)
+
+// Store 配置文件存储
+// 在 YAML 节点树上修改摄像头列表，尽量保留原有注释和字段顺序，写入时使用临时文件 + 重命名保证原子性
+type Store struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewStore 创建配置文件存储
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// AddCamera 追加摄像头配置，cam 应为用户提交的原样配置（不含默认值）
+func (s *Store) AddCamera(cam CameraConfig) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load()
+	if err != nil {
+		return err
+	}
+	cameras, err := camerasNode(doc, true)
+	if err != nil {
+		return err
+	}
+	if findCamera(cameras, cam.ID) >= 0 {
+		return fmt.Errorf("%w: %s", ErrCameraExists, cam.ID)
+	}
+
+	node, err := encodeCamera(cam)
+	if err != nil {
+		return err
+	}
+	pruneZero(node)
+	cameras.Content = append(cameras.Content, node)
+
+	return s.save(doc)
+}
+
+// UpdateCamera 更新摄像头配置，未变化字段的注释保持不变；cam 应为用户提交的原样配置（不含默认值）
+func (s *Store) UpdateCamera(cam CameraConfig) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load()
+	if err != nil {
+		return err
+	}
+	cameras, err := camerasNode(doc, false)
+	if err != nil {
+		return err
+	}
+	index := findCamera(cameras, cam.ID)
+	if index < 0 {
+		return fmt.Errorf("%w: %s", ErrCameraNotFound, cam.ID)
+	}
+
+	node, err := encodeCamera(cam)
+	if err != nil {
+		return err
+	}
+	mergeMapping(cameras.Content[index], node)
+
+	return s.save(doc)
+}
+
+// GetCamera 读取配置文件中的摄像头配置（原样，未设置默认值），禁用的摄像头同样可读取
+func (s *Store) GetCamera(id string) (CameraConfig, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var cam CameraConfig
+	doc, err := s.load()
+	if err != nil {
+		return cam, err
+	}
+	cameras, err := camerasNode(doc, false)
+	if err != nil {
+		return cam, err
+	}
+	index := findCamera(cameras, id)
+	if index < 0 {
+		return cam, fmt.Errorf("%w: %s", ErrCameraNotFound, id)
+	}
+	if err := cameras.Content[index].Decode(&cam); err != nil {
+		return cam, fmt.Errorf("解析摄像头配置失败: %w", err)
+	}
+	return cam, nil
+}
+
+// DeleteCamera 删除摄像头配置
+func (s *Store) DeleteCamera(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	doc, err := s.load()
+	if err != nil {
+		return err
+	}
+	cameras, err := camerasNode(doc, false)
+	if err != nil {
+		return err
+	}
+	index := findCamera(cameras, id)
+	if index < 0 {
+		return fmt.Errorf("%w: %s", ErrCameraNotFound, id)
+	}
+
+	cameras.Content = append(cameras.Content[:index], cameras.Content[index+1:]...)
+	return s.save(doc)
+}
+
+// load 读取配置文件为节点树
+func (s *Store) load() (*yaml.Node, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("配置文件格式错误")
+	}
+	return &doc, nil
+}
+
+// save 原子写入配置文件：先写同目录临时文件再重命名
+func (s *Store) save(doc *yaml.Node) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	encoder.Close()
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(s.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("替换配置文件失败: %w", err)
+	}
+	return nil
+}
+
+// camerasNode 查找 cameras 序列节点，create 为 true 时不存在则创建
+func camerasNode(doc *yaml.Node, create bool) (*yaml.Node, error) {
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "cameras" {
+			node := root.Content[i+1]
+			if node.Kind == yaml.SequenceNode {
+				return node, nil
+			}
+			// cameras: 为空时是 null 标量，改为序列
+			if node.Tag == "!!null" && create {
+				*node = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+				return node, nil
+			}
+			return nil, fmt.Errorf("配置文件中 cameras 不是列表")
+		}
+	}
+	if !create {
+		return nil, fmt.Errorf("配置文件中没有 cameras")
+	}
+
+	node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	root.Content = append(root.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "cameras"},
+		node,
+	)
+	return node, nil
+}
+
+// findCamera 按 ID 查找摄像头在序列中的下标，不存在返回 -1
+func findCamera(cameras *yaml.Node, id string) int {
+	for i, item := range cameras.Content {
+		if value := mappingValue(item, "id"); value != nil && value.Value == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingValue 获取映射节点中指定键的值
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// encodeCamera 将摄像头配置编码为映射节点，字符串值与示例配置一样使用双引号
+func encodeCamera(cam CameraConfig) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(cam); err != nil {
+		return nil, fmt.Errorf("编码摄像头配置失败: %w", err)
+	}
+	quoteStrings(&node)
+	return &node, nil
+}
+
+// quoteStrings 将映射中的字符串值设为双引号风格
+func quoteStrings(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 1; i < len(node.Content); i += 2 {
+		value := node.Content[i]
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!str" {
+			value.Style = yaml.DoubleQuotedStyle
+		}
+		quoteStrings(value)
+	}
+}
+
+// mergeMapping 将 src 的值合并到 dst
+// 已有的键原地修改值（保留注释和引号风格），新出现的非零值追加到末尾
+func mergeMapping(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		existing := mappingValue(dst, key.Value)
+
+		switch {
+		case existing == nil:
+			pruneZero(value)
+			if isZero(value) {
+				continue
+			}
+			dst.Content = append(dst.Content, key, value)
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeMapping(existing, value)
+		case existing.Kind == yaml.ScalarNode && value.Kind == yaml.ScalarNode:
+			if existing.Value == value.Value && existing.Tag == value.Tag {
+				continue
+			}
+			if existing.Tag != value.Tag {
+				existing.Style = value.Style
+			}
+			existing.Tag = value.Tag
+			existing.Value = value.Value
+		default:
+			value.HeadComment = existing.HeadComment
+			value.LineComment = existing.LineComment
+			*existing = *value
+		}
+	}
+}
+
+// pruneZero 删除映射中的零值字段，避免新增条目写入大量空字段
+func pruneZero(node *yaml.Node) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	content := node.Content[:0]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		pruneZero(value)
+		if isZero(value) {
+			continue
+		}
+		content = append(content, key, value)
+	}
+	node.Content = content
+}
+
+// isZero 判断节点是否为零值（空字符串、0、false、空映射）
+func isZero(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!str":
+			return node.Value == ""
+		case "!!int":
+			return node.Value == "0"
+		case "!!bool":
+			return node.Value == "false"
+		case "!!null":
+			return true
+		}
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(node.Content) == 0
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TransformConfig 画面变换，按 去隔行 → 降噪 → 裁剪 → 翻转 → 旋转 的顺序应用到预览和录像
+// 隐私遮挡坐标和 OSD 位置以变换后的画面为准
+type TransformConfig struct {
+	Rotate      int        `yaml:"rotate" json:"rotate"`           // 顺时针旋转角度: 0, 90, 180, 270
+	HFlip       bool       `yaml:"hflip" json:"hflip"`             // 水平翻转
+	VFlip       bool       `yaml:"vflip" json:"vflip"`             // 垂直翻转
+	Crop        CropConfig `yaml:"crop" json:"crop"`               // 裁剪区域，宽高为 0 表示不裁剪
+	Deinterlace bool       `yaml:"deinterlace" json:"deinterlace"` // 去隔行（yadif）
+	Denoise     bool       `yaml:"denoise" json:"denoise"`         // 降噪（hqdn3d），会增加 CPU 占用
+}
+
+// CropConfig 裁剪区域，按源画面宽高归一化到 0-1，左上角为原点
+type CropConfig struct {
+	X      float64 `yaml:"x" json:"x"`
+	Y      float64 `yaml:"y" json:"y"`
+	Width  float64 `yaml:"width" json:"width"`
+	Height float64 `yaml:"height" json:"height"`
+}
+
+// cropped 是否配置了裁剪
+func (c *CropConfig) cropped() bool {
+	return c.Width > 0 && c.Height > 0 && (c.Width < 1 || c.Height < 1)
+}
+
+// Enabled 是否配置了任何变换
+func (t *TransformConfig) Enabled() bool {
+	return t.Rotate != 0 || t.HFlip || t.VFlip || t.Crop.cropped() || t.Deinterlace || t.Denoise
+}
+
+// validate 校验画面变换
+func (t *TransformConfig) validate() error {
+	switch t.Rotate {
+	case 0, 90, 180, 270:
+	default:
+		return fmt.Errorf("无效的旋转角度: %d（0, 90, 180, 270）", t.Rotate)
+	}
+
+	c := t.Crop
+	if c == (CropConfig{}) {
+		return nil
+	}
+	for _, v := range []float64{c.X, c.Y, c.Width, c.Height} {
+		if math.IsNaN(v) || v < 0 || v > 1 {
+			return fmt.Errorf("无效的裁剪区域: 坐标和宽高需归一化到 0-1")
+		}
+	}
+	if c.Width == 0 || c.Height == 0 {
+		return fmt.Errorf("裁剪区域需要 width 和 height")
+	}
+	if c.X+c.Width > 1 || c.Y+c.Height > 1 {
+		return fmt.Errorf("裁剪区域超出画面")
+	}
+	return nil
+}
+
+// OutputSize 变换后的输出尺寸，width x height 为变换前的画面尺寸
+// 裁剪按比例缩小，旋转 90/270 度时宽高互换，结果取偶数（编码器要求）
+func (t *TransformConfig) OutputSize(width, height int) (int, int) {
+	if width <= 0 || height <= 0 {
+		return width, height
+	}
+	if t.Crop.cropped() {
+		width = evenSize(float64(width) * t.Crop.Width)
+		height = evenSize(float64(height) * t.Crop.Height)
+	}
+	if t.Rotate == 90 || t.Rotate == 270 {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// evenSize 四舍五入到不小于 2 的偶数
+func evenSize(v float64) int {
+	return max(2, int(math.Round(v/2))*2)
+}
+
+// Filters 生成 FFmpeg 滤镜链（逗号分隔），无变换时为空
+func (t *TransformConfig) Filters() string {
+	var filters []string
+	if t.Deinterlace {
+		filters = append(filters, "yadif")
+	}
+	if t.Denoise {
+		filters = append(filters, "hqdn3d")
+	}
+	if t.Crop.cropped() {
+		// 宽高取偶数，避免 yuv420p 编码失败
+		filters = append(filters, fmt.Sprintf("crop=w=trunc(iw*%g/2)*2:h=trunc(ih*%g/2)*2:x=iw*%g:y=ih*%g",
+			t.Crop.Width, t.Crop.Height, t.Crop.X, t.Crop.Y))
+	}
+	if t.HFlip {
+		filters = append(filters, "hflip")
+	}
+	if t.VFlip {
+		filters = append(filters, "vflip")
+	}
+	switch t.Rotate {
+	case 90:
+		filters = append(filters, "transpose=clock")
+	case 180:
+		filters = append(filters, "hflip", "vflip")
+	case 270:
+		filters = append(filters, "transpose=cclock")
+	}
+	return strings.Join(filters, ",")
+}
@@ -0,0 +1,106 @@
+//go:build linux
+
+package devices
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ListAudioDevices 枚举 ALSA 录音设备和 PulseAudio source
+// 未安装 pactl 或 PulseAudio 未运行时只返回 ALSA 设备
+func ListAudioDevices() ([]AudioDevice, error) {
+	devices, err := listALSACaptureDevices()
+	if err != nil {
+		return nil, err
+	}
+	devices = append(devices, listPulseSources()...)
+	return devices, nil
+}
+
+// listALSACaptureDevices 解析 /proc/asound/pcm 中支持录音的设备
+// 行格式: "00-00: ALC892 Analog : ALC892 Analog : playback 1 : capture 1"
+func listALSACaptureDevices() ([]AudioDevice, error) {
+	devices := []AudioDevice{}
+
+	f, err := os.Open("/proc/asound/pcm")
+	if err != nil {
+		if os.IsNotExist(err) {
+			// 没有声卡
+			return devices, nil
+		}
+		return nil, fmt.Errorf("读取 ALSA 设备失败: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 3 {
+			continue
+		}
+
+		hasCapture := false
+		for _, field := range fields[3:] {
+			if strings.HasPrefix(strings.TrimSpace(field), "capture") {
+				hasCapture = true
+			}
+		}
+		if !hasCapture {
+			continue
+		}
+
+		var card, device int
+		if _, err := fmt.Sscanf(fields[0], "%d-%d", &card, &device); err != nil {
+			continue
+		}
+		devices = append(devices, AudioDevice{
+			Type:        "alsa",
+			Index:       card,
+			DeviceName:  fmt.Sprintf("hw:%d,%d", card, device),
+			Description: strings.TrimSpace(fields[2]),
+		})
+	}
+	return devices, scanner.Err()
+}
+
+// listPulseSources 通过 pactl 列出 PulseAudio/PipeWire 录音 source（跳过 .monitor 回环）
+// 行格式: "1\talsa_input.pci-0000_00_1f.3.analog-stereo\tmodule-alsa-card.c\ts16le 2ch 44100Hz\tSUSPENDED"
+func listPulseSources() []AudioDevice {
+	output, err := exec.Command("pactl", "list", "short", "sources").Output()
+	if err != nil {
+		return nil
+	}
+
+	var devices []AudioDevice
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 4 || strings.HasSuffix(fields[1], ".monitor") {
+			continue
+		}
+
+		index, _ := strconv.Atoi(fields[0])
+		dev := AudioDevice{
+			Type:        "pulse",
+			Index:       index,
+			DeviceName:  fields[1],
+			Description: fields[1],
+		}
+		for _, spec := range strings.Fields(fields[3]) {
+			switch {
+			case strings.HasSuffix(spec, "ch"):
+				dev.Channels, _ = strconv.Atoi(strings.TrimSuffix(spec, "ch"))
+			case strings.HasSuffix(spec, "Hz"):
+				dev.SampleRate, _ = strconv.Atoi(strings.TrimSuffix(spec, "Hz"))
+			}
+		}
+		devices = append(devices, dev)
+	}
+	return devices
+}
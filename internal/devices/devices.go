@@ -0,0 +1,126 @@
+// Package devices 本地采集设备发现
+// 枚举 V4L2 视频设备及其支持的像素格式、分辨率和帧率，以及 ALSA/PulseAudio 录音设备
+package devices
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"home-monitor/internal/config"
+)
+
+// ErrUnsupported 当前平台不支持设备枚举
+var ErrUnsupported = errors.New("当前平台不支持设备枚举")
+
+// Inventory 本地设备清单
+type Inventory struct {
+	Video []VideoDevice `json:"video"`
+	Audio []AudioDevice `json:"audio"`
+}
+
+// VideoDevice V4L2 视频采集设备
+type VideoDevice struct {
+	Index   int           `json:"index"` // 对应摄像头配置的 device_index（/dev/videoN）
+	Path    string        `json:"path"`
+	Name    string        `json:"name"`
+	Driver  string        `json:"driver"`
+	BusInfo string        `json:"bus_info"`
+	Formats []VideoFormat `json:"formats"`
+}
+
+// VideoFormat 像素格式及其支持的分辨率
+type VideoFormat struct {
+	FourCC      string      `json:"fourcc"` // 如 MJPG、YUYV
+	Description string      `json:"description"`
+	Sizes       []FrameSize `json:"sizes"`
+}
+
+// FrameSize 分辨率及该分辨率下支持的帧率
+type FrameSize struct {
+	Width  int       `json:"width"`
+	Height int       `json:"height"`
+	FPS    []float64 `json:"fps"`
+}
+
+// AudioDevice 录音设备
+type AudioDevice struct {
+	Type        string `json:"type"`         // alsa, pulse（对应音频配置的 type）
+	Index       int    `json:"device_index"` // ALSA 声卡号（对应音频配置的 device_index）
+	DeviceName  string `json:"device_name"`  // ALSA 为 hw:卡,设备；PulseAudio 为 source 名称（对应音频配置的 device_name）
+	Description string `json:"description"`
+	SampleRate  int    `json:"sample_rate,omitempty"`
+	Channels    int    `json:"channels,omitempty"`
+}
+
+// List 枚举本地设备，单类设备枚举失败时记录日志并返回空列表
+func List() Inventory {
+	inv := Inventory{
+		Video: []VideoDevice{},
+		Audio: []AudioDevice{},
+	}
+
+	if video, err := ListVideoDevices(); err != nil {
+		if !errors.Is(err, ErrUnsupported) {
+			log.Printf("枚举视频设备失败: %v", err)
+		}
+	} else {
+		inv.Video = video
+	}
+
+	if audio, err := ListAudioDevices(); err != nil {
+		if !errors.Is(err, ErrUnsupported) {
+			log.Printf("枚举音频设备失败: %v", err)
+		}
+	} else {
+		inv.Audio = audio
+	}
+
+	return inv
+}
+
+// Supports 检查设备是否支持指定分辨率和帧率（任一像素格式满足即可）
+func (d *VideoDevice) Supports(width, height, fps int) error {
+	sizeFound := false
+	for _, format := range d.Formats {
+		for _, size := range format.Sizes {
+			if size.Width != width || size.Height != height {
+				continue
+			}
+			sizeFound = true
+			for _, rate := range size.FPS {
+				if math.Abs(rate-float64(fps)) < 0.5 {
+					return nil
+				}
+			}
+		}
+	}
+
+	if !sizeFound {
+		return fmt.Errorf("设备 %s 不支持分辨率 %dx%d", d.Path, width, height)
+	}
+	return fmt.Errorf("设备 %s 在 %dx%d 下不支持 %d fps", d.Path, width, height, fps)
+}
+
+// ValidateCamera 按设备实际能力校验启用的 USB 摄像头配置
+// 其他来源类型或当前平台无法枚举设备时不做检查
+func ValidateCamera(cfg config.CameraConfig) error {
+	if !cfg.Enabled || (cfg.Type != "" && cfg.Type != "usb") {
+		return nil
+	}
+
+	dev, err := FindVideoDevice(cfg.DeviceIndex)
+	if err != nil {
+		if errors.Is(err, ErrUnsupported) {
+			return nil
+		}
+		return err
+	}
+
+	// 驱动未报告任何格式时无法判断，交由 FFmpeg 协商
+	if len(dev.Formats) == 0 {
+		return nil
+	}
+	return dev.Supports(cfg.Width, cfg.Height, cfg.FPS)
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package devices
+
+// ListVideoDevices 当前平台不支持 V4L2 设备枚举
+func ListVideoDevices() ([]VideoDevice, error) {
+	return nil, ErrUnsupported
+}
+
+// FindVideoDevice 当前平台不支持 V4L2 设备枚举
+func FindVideoDevice(index int) (*VideoDevice, error) {
+	return nil, ErrUnsupported
+}
+
+// ListAudioDevices 当前平台不支持音频设备枚举
+func ListAudioDevices() ([]AudioDevice, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,283 @@
+//go:build linux
+
+package devices
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// V4L2 ioctl 请求码（linux/videodev2.h）
+const (
+	vidiocQueryCap           = 0x80685600 // _IOR('V', 0, struct v4l2_capability)
+	vidiocEnumFmt            = 0xc0405602 // _IOWR('V', 2, struct v4l2_fmtdesc)
+	vidiocEnumFrameSizes     = 0xc02c564a // _IOWR('V', 74, struct v4l2_frmsizeenum)
+	vidiocEnumFrameIntervals = 0xc034564b // _IOWR('V', 75, struct v4l2_frmivalenum)
+)
+
+// V4L2 常量
+const (
+	v4l2BufTypeVideoCapture = 1
+	v4l2CapVideoCapture     = 0x00000001
+	v4l2CapDeviceCaps       = 0x80000000
+	v4l2FrmTypeDiscrete     = 1
+	v4l2FrmTypeContinuous   = 2
+
+	maxEnumEntries = 256 // 防止异常驱动无限枚举
+)
+
+// v4l2Capability struct v4l2_capability
+type v4l2Capability struct {
+	Driver       [16]byte
+	Card         [32]byte
+	BusInfo      [32]byte
+	Version      uint32
+	Capabilities uint32
+	DeviceCaps   uint32
+	Reserved     [3]uint32
+}
+
+// v4l2FmtDesc struct v4l2_fmtdesc
+type v4l2FmtDesc struct {
+	Index       uint32
+	Type        uint32
+	Flags       uint32
+	Description [32]byte
+	PixelFormat uint32
+	MbusCode    uint32
+	Reserved    [3]uint32
+}
+
+// v4l2FrmSizeEnum struct v4l2_frmsizeenum
+// 联合体: discrete {width, height} 或 stepwise {min_w, max_w, step_w, min_h, max_h, step_h}
+type v4l2FrmSizeEnum struct {
+	Index       uint32
+	PixelFormat uint32
+	Type        uint32
+	Union       [6]uint32
+	Reserved    [2]uint32
+}
+
+// v4l2FrmIvalEnum struct v4l2_frmivalenum
+// 联合体: discrete {num, den} 或 stepwise {min, max, step}（均为分数）
+type v4l2FrmIvalEnum struct {
+	Index       uint32
+	PixelFormat uint32
+	Width       uint32
+	Height      uint32
+	Type        uint32
+	Union       [6]uint32
+	Reserved    [2]uint32
+}
+
+// 步进/连续帧率只列出常见帧率
+var commonFrameRates = []float64{5, 10, 15, 20, 25, 30, 50, 60}
+
+// 步进/连续分辨率设备只列出常见分辨率
+var commonSizes = [][2]int{
+	{320, 240}, {640, 360}, {640, 480}, {800, 600},
+	{1024, 768}, {1280, 720}, {1280, 960}, {1920, 1080},
+	{2560, 1440}, {3840, 2160},
+}
+
+// ListVideoDevices 枚举 /dev/video* 中的视频采集设备
+// 跳过元数据等不支持视频采集的节点
+func ListVideoDevices() ([]VideoDevice, error) {
+	paths, err := filepath.Glob("/dev/video*")
+	if err != nil {
+		return nil, err
+	}
+
+	devices := []VideoDevice{}
+	for _, path := range paths {
+		index, err := strconv.Atoi(strings.TrimPrefix(path, "/dev/video"))
+		if err != nil {
+			continue
+		}
+		dev, err := queryVideoDevice(index, path)
+		if err != nil {
+			continue
+		}
+		devices = append(devices, *dev)
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Index < devices[j].Index
+	})
+	return devices, nil
+}
+
+// FindVideoDevice 查询指定索引的视频采集设备
+func FindVideoDevice(index int) (*VideoDevice, error) {
+	return queryVideoDevice(index, fmt.Sprintf("/dev/video%d", index))
+}
+
+// queryVideoDevice 打开设备并查询能力与格式
+func queryVideoDevice(index int, path string) (*VideoDevice, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("打开视频设备 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+	fd := f.Fd()
+
+	var caps v4l2Capability
+	if err := ioctl(fd, vidiocQueryCap, unsafe.Pointer(&caps)); err != nil {
+		return nil, fmt.Errorf("%s 不是 V4L2 设备: %w", path, err)
+	}
+
+	capabilities := caps.Capabilities
+	if capabilities&v4l2CapDeviceCaps != 0 {
+		capabilities = caps.DeviceCaps
+	}
+	if capabilities&v4l2CapVideoCapture == 0 {
+		return nil, fmt.Errorf("%s 不支持视频采集", path)
+	}
+
+	return &VideoDevice{
+		Index:   index,
+		Path:    path,
+		Name:    cString(caps.Card[:]),
+		Driver:  cString(caps.Driver[:]),
+		BusInfo: cString(caps.BusInfo[:]),
+		Formats: enumFormats(fd),
+	}, nil
+}
+
+// enumFormats 枚举像素格式
+func enumFormats(fd uintptr) []VideoFormat {
+	formats := []VideoFormat{}
+	for i := uint32(0); i < maxEnumEntries; i++ {
+		desc := v4l2FmtDesc{Index: i, Type: v4l2BufTypeVideoCapture}
+		if err := ioctl(fd, vidiocEnumFmt, unsafe.Pointer(&desc)); err != nil {
+			break
+		}
+		formats = append(formats, VideoFormat{
+			FourCC:      fourCC(desc.PixelFormat),
+			Description: cString(desc.Description[:]),
+			Sizes:       enumFrameSizes(fd, desc.PixelFormat),
+		})
+	}
+	return formats
+}
+
+// enumFrameSizes 枚举指定像素格式的分辨率
+func enumFrameSizes(fd uintptr, pixelFormat uint32) []FrameSize {
+	sizes := []FrameSize{}
+	for i := uint32(0); i < maxEnumEntries; i++ {
+		frmSize := v4l2FrmSizeEnum{Index: i, PixelFormat: pixelFormat}
+		if err := ioctl(fd, vidiocEnumFrameSizes, unsafe.Pointer(&frmSize)); err != nil {
+			break
+		}
+
+		if frmSize.Type == v4l2FrmTypeDiscrete {
+			width, height := frmSize.Union[0], frmSize.Union[1]
+			sizes = append(sizes, FrameSize{
+				Width:  int(width),
+				Height: int(height),
+				FPS:    enumFrameRates(fd, pixelFormat, width, height),
+			})
+			continue
+		}
+
+		// 步进/连续：只有一个条目，列出范围内的常见分辨率
+		minW, maxW, stepW := frmSize.Union[0], frmSize.Union[1], frmSize.Union[2]
+		minH, maxH, stepH := frmSize.Union[3], frmSize.Union[4], frmSize.Union[5]
+		if frmSize.Type == v4l2FrmTypeContinuous {
+			stepW, stepH = 1, 1
+		}
+		for _, size := range commonSizes {
+			width, height := uint32(size[0]), uint32(size[1])
+			if !inStep(width, minW, maxW, stepW) || !inStep(height, minH, maxH, stepH) {
+				continue
+			}
+			sizes = append(sizes, FrameSize{
+				Width:  size[0],
+				Height: size[1],
+				FPS:    enumFrameRates(fd, pixelFormat, width, height),
+			})
+		}
+		break
+	}
+	return sizes
+}
+
+// enumFrameRates 枚举指定分辨率下的帧率（帧间隔的倒数）
+func enumFrameRates(fd uintptr, pixelFormat, width, height uint32) []float64 {
+	rates := []float64{}
+	for i := uint32(0); i < maxEnumEntries; i++ {
+		ival := v4l2FrmIvalEnum{Index: i, PixelFormat: pixelFormat, Width: width, Height: height}
+		if err := ioctl(fd, vidiocEnumFrameIntervals, unsafe.Pointer(&ival)); err != nil {
+			break
+		}
+
+		if ival.Type == v4l2FrmTypeDiscrete {
+			if rate := fractionRate(ival.Union[0], ival.Union[1]); rate > 0 {
+				rates = append(rates, rate)
+			}
+			continue
+		}
+
+		// 步进/连续：最小间隔对应最大帧率，列出范围内的常见帧率
+		maxRate := fractionRate(ival.Union[0], ival.Union[1])
+		minRate := fractionRate(ival.Union[2], ival.Union[3])
+		for _, rate := range commonFrameRates {
+			if rate >= minRate && rate <= maxRate {
+				rates = append(rates, rate)
+			}
+		}
+		break
+	}
+
+	sort.Float64s(rates)
+	return rates
+}
+
+// ioctl 执行 ioctl 系统调用
+func ioctl(fd uintptr, request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// fractionRate 将帧间隔 num/den 秒换算为帧率，保留两位小数
+func fractionRate(num, den uint32) float64 {
+	if num == 0 {
+		return 0
+	}
+	rate := float64(den) / float64(num)
+	return float64(int(rate*100+0.5)) / 100
+}
+
+// inStep 判断值是否在范围内且符合步进
+func inStep(v, min, max, step uint32) bool {
+	if v < min || v > max {
+		return false
+	}
+	return step == 0 || (v-min)%step == 0
+}
+
+// fourCC 像素格式代码转字符串
+func fourCC(code uint32) string {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, code)
+	return strings.TrimSpace(string(b))
+}
+
+// cString 截取以 NUL 结尾的 C 字符串
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
@@ -0,0 +1,369 @@
+// Package ffmpeglog 收集各摄像头 FFmpeg 进程的 stderr 输出
+// 每个摄像头的每路管线（采集、录像、HLS、RTMP、WebRTC）各有一个有界环形缓冲区，
+// 按级别解析后供 API 查询和实时订阅，只有限速后的警告和错误会写入主日志。
+package ffmpeglog
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 管线名称
+// FFmpeg 类摄像头的分段录像由采集进程输出，日志记在 capture 下；recording 只用于推送型摄像头的独立编码进程
+const (
+	PipelineCapture     = "capture"      // FFmpeg 采集进程（预览、音频与录像输出）
+	PipelineRecording   = "recording"    // 推送型摄像头的录像编码进程
+	PipelineHLS         = "hls"          // HLS 输出编码进程
+	PipelineRTMP        = "rtmp"         // RTMP 推流编码进程
+	PipelineWebRTCVideo = "webrtc_video" // WebRTC VP8 编码进程
+	PipelineWebRTCAudio = "webrtc_audio" // WebRTC Opus 编码进程
+)
+
+// pipelineNames 所有管线名称
+var pipelineNames = []string{
+	PipelineCapture,
+	PipelineRecording,
+	PipelineHLS,
+	PipelineRTMP,
+	PipelineWebRTCVideo,
+	PipelineWebRTCAudio,
+}
+
+// IsPipeline 是否为有效的管线名称
+func IsPipeline(name string) bool {
+	return slices.Contains(pipelineNames, name)
+}
+
+// PipelineNames 所有管线名称
+func PipelineNames() []string {
+	return append([]string(nil), pipelineNames...)
+}
+
+const (
+	// 每路管线保留的日志行数
+	bufferSize = 500
+	// 单行最大长度，超出部分截断
+	maxLineLength = 4096
+	// 写入主日志的限速：每路管线每个窗口最多 forwardBurst 行
+	forwardWindow = 10 * time.Second
+	forwardBurst  = 5
+	// 实时订阅者的缓冲行数，跟不上时丢弃
+	subscriberBuffer = 100
+)
+
+// Level 日志级别
+type Level string
+
+const (
+	LevelError   Level = "error"
+	LevelWarning Level = "warning"
+	LevelInfo    Level = "info"
+	LevelDebug   Level = "debug"
+)
+
+// rank 级别严重程度，越小越严重
+func (l Level) rank() int {
+	switch l {
+	case LevelError:
+		return 0
+	case LevelWarning:
+		return 1
+	case LevelInfo:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// AtLeast 是否不低于 minLevel 的严重程度
+func (l Level) AtLeast(minLevel Level) bool {
+	return l.rank() <= minLevel.rank()
+}
+
+// ParseLevel 解析级别名称
+func ParseLevel(s string) (Level, bool) {
+	switch Level(s) {
+	case LevelError, LevelWarning, LevelInfo, LevelDebug:
+		return Level(s), true
+	}
+	return "", false
+}
+
+// Entry 一行日志
+type Entry struct {
+	Seq      uint64    `json:"seq"` // 管线内递增序号
+	Time     time.Time `json:"time"`
+	CameraID string    `json:"camera_id"`
+	Pipeline string    `json:"pipeline"`
+	Level    Level     `json:"level"`
+	Message  string    `json:"message"`
+}
+
+// buffer 单路管线的环形缓冲区及主日志限速状态
+type buffer struct {
+	entries []Entry
+	next    int
+	seq     uint64
+
+	windowStart time.Time
+	forwarded   int
+	suppressed  int
+}
+
+// add 追加一行，返回带序号的条目
+func (b *buffer) add(entry Entry) Entry {
+	b.seq++
+	entry.Seq = b.seq
+	if len(b.entries) < bufferSize {
+		b.entries = append(b.entries, entry)
+	} else {
+		b.entries[b.next] = entry
+		b.next = (b.next + 1) % bufferSize
+	}
+	return entry
+}
+
+// snapshot 按时间顺序复制缓冲区内容
+func (b *buffer) snapshot() []Entry {
+	out := make([]Entry, 0, len(b.entries))
+	out = append(out, b.entries[b.next:]...)
+	return append(out, b.entries[:b.next]...)
+}
+
+// allowForward 限速判断是否写入主日志，返回此前被抑制的行数
+func (b *buffer) allowForward(now time.Time) (bool, int) {
+	if now.Sub(b.windowStart) >= forwardWindow {
+		suppressed := b.suppressed
+		b.windowStart = now
+		b.forwarded = 1
+		b.suppressed = 0
+		return true, suppressed
+	}
+	if b.forwarded < forwardBurst {
+		b.forwarded++
+		return true, 0
+	}
+	b.suppressed++
+	return false, 0
+}
+
+// registry 按摄像头、管线组织的日志缓冲区
+type registry struct {
+	buffers     map[string]map[string]*buffer      // cameraID -> pipeline -> buffer
+	subscribers map[string]map[chan Entry]struct{} // cameraID -> 实时订阅者
+	mutex       sync.Mutex
+}
+
+var logs = &registry{
+	buffers:     make(map[string]map[string]*buffer),
+	subscribers: make(map[string]map[chan Entry]struct{}),
+}
+
+// record 记录一行日志并推送给实时订阅者
+func (r *registry) record(entry Entry) {
+	r.mutex.Lock()
+	pipelines, exists := r.buffers[entry.CameraID]
+	if !exists {
+		pipelines = make(map[string]*buffer)
+		r.buffers[entry.CameraID] = pipelines
+	}
+	buf, exists := pipelines[entry.Pipeline]
+	if !exists {
+		buf = &buffer{}
+		pipelines[entry.Pipeline] = buf
+	}
+
+	entry = buf.add(entry)
+
+	forward, suppressed := false, 0
+	if entry.Level.AtLeast(LevelWarning) {
+		forward, suppressed = buf.allowForward(entry.Time)
+	}
+
+	for ch := range r.subscribers[entry.CameraID] {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+	r.mutex.Unlock()
+
+	if suppressed > 0 {
+		log.Printf("FFmpeg [%s/%s]: 已抑制 %d 行日志，完整日志见 /api/cameras/%s/logs",
+			entry.CameraID, entry.Pipeline, suppressed, entry.CameraID)
+	}
+	if forward {
+		log.Printf("FFmpeg [%s/%s] %s: %s", entry.CameraID, entry.Pipeline, entry.Level, entry.Message)
+	}
+}
+
+// Entries 获取摄像头的日志，pipeline 为空时合并所有管线
+// 只返回不低于 minLevel 的最近 limit 行（limit <= 0 表示不限），按时间排序
+func Entries(cameraID, pipeline string, minLevel Level, limit int) []Entry {
+	logs.mutex.Lock()
+	var entries []Entry
+	for name, buf := range logs.buffers[cameraID] {
+		if pipeline != "" && name != pipeline {
+			continue
+		}
+		for _, entry := range buf.snapshot() {
+			if entry.Level.AtLeast(minLevel) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	logs.mutex.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// Pipelines 获取摄像头已产生日志的管线名称
+func Pipelines(cameraID string) []string {
+	logs.mutex.Lock()
+	defer logs.mutex.Unlock()
+
+	names := make([]string, 0, len(logs.buffers[cameraID]))
+	for name := range logs.buffers[cameraID] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Remove 删除摄像头的所有日志缓冲区（摄像头被删除时调用），实时订阅者保持不变
+func Remove(cameraID string) {
+	logs.mutex.Lock()
+	delete(logs.buffers, cameraID)
+	logs.mutex.Unlock()
+}
+
+// Subscribe 实时订阅摄像头的新日志，返回的函数用于取消订阅
+func Subscribe(cameraID string) (<-chan Entry, func()) {
+	ch := make(chan Entry, subscriberBuffer)
+
+	logs.mutex.Lock()
+	if logs.subscribers[cameraID] == nil {
+		logs.subscribers[cameraID] = make(map[chan Entry]struct{})
+	}
+	logs.subscribers[cameraID][ch] = struct{}{}
+	logs.mutex.Unlock()
+
+	return ch, func() {
+		logs.mutex.Lock()
+		delete(logs.subscribers[cameraID], ch)
+		if len(logs.subscribers[cameraID]) == 0 {
+			delete(logs.subscribers, cameraID)
+		}
+		logs.mutex.Unlock()
+	}
+}
+
+// Writer 创建写入指定摄像头、管线日志的 io.Writer，用作 exec.Cmd 的 Stderr
+// 按行切分（\n 或 \r），每个进程使用一个新的 Writer
+func Writer(cameraID, pipeline string) io.Writer {
+	return &lineWriter{cameraID: cameraID, pipeline: pipeline}
+}
+
+// lineWriter 将 stderr 字节流切分为行
+type lineWriter struct {
+	cameraID string
+	pipeline string
+	pending  []byte
+	mutex    sync.Mutex
+}
+
+// Write 实现 io.Writer
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.pending = append(w.pending, p...)
+	for {
+		idx := bytes.IndexAny(w.pending, "\r\n")
+		if idx < 0 {
+			break
+		}
+		w.emit(w.pending[:idx])
+		w.pending = w.pending[idx+1:]
+	}
+
+	// 没有换行的超长输出直接截断成一行
+	if len(w.pending) > maxLineLength {
+		w.emit(w.pending)
+		w.pending = nil
+	}
+	return len(p), nil
+}
+
+// emit 解析并记录一行
+func (w *lineWriter) emit(line []byte) {
+	text := strings.TrimSpace(string(line))
+	if text == "" {
+		return
+	}
+	if len(text) > maxLineLength {
+		text = text[:maxLineLength]
+	}
+
+	level, message := parseLine(redactCredentials(text))
+	logs.record(Entry{
+		Time:     time.Now(),
+		CameraID: w.cameraID,
+		Pipeline: w.pipeline,
+		Level:    level,
+		Message:  message,
+	})
+}
+
+// credentialPattern URL 中的 user:password@ 部分
+var credentialPattern = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s]+@`)
+
+// redactCredentials 隐藏日志中 URL 携带的密码
+func redactCredentials(text string) string {
+	return credentialPattern.ReplaceAllString(text, "${1}******@")
+}
+
+// levelTagPattern -loglevel level+... 输出的级别标签，位于上下文前缀（如 [h264 @ 0x...]）之后
+var levelTagPattern = regexp.MustCompile(`\[(panic|fatal|error|warning|info|verbose|debug|trace)\] `)
+
+// parseLine 解析一行的级别，去掉级别标签
+// 没有级别标签时按关键字推断
+func parseLine(text string) (Level, string) {
+	if loc := levelTagPattern.FindStringSubmatchIndex(text); loc != nil {
+		tag := text[loc[2]:loc[3]]
+		message := text[:loc[0]] + text[loc[1]:]
+		switch tag {
+		case "panic", "fatal", "error":
+			return LevelError, message
+		case "warning":
+			return LevelWarning, message
+		case "info", "verbose":
+			return LevelInfo, message
+		default:
+			return LevelDebug, message
+		}
+	}
+
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "error"), strings.Contains(lower, "failed"), strings.Contains(lower, "invalid"):
+		return LevelError, text
+	case strings.Contains(lower, "warning"), strings.Contains(lower, "deprecated"):
+		return LevelWarning, text
+	default:
+		return LevelInfo, text
+	}
+}
@@ -0,0 +1,39 @@
+package ffmpeglog
+
+import "testing"
+
+func TestRemove(t *testing.T) {
+	w := Writer("removed", PipelineCapture)
+	w.Write([]byte("[error] Connection refused\n[info] Stream #0:0: Video: h264\n"))
+	keep := Writer("kept", PipelineCapture)
+	keep.Write([]byte("[warning] non-monotonic DTS\n"))
+
+	if got := len(Entries("removed", "", LevelDebug, 0)); got != 2 {
+		t.Fatalf("删除前 Entries = %d 行, want 2", got)
+	}
+
+	Remove("removed")
+	if got := Entries("removed", "", LevelDebug, 0); len(got) != 0 {
+		t.Fatalf("删除后仍有日志: %v", got)
+	}
+	if got := Pipelines("removed"); len(got) != 0 {
+		t.Fatalf("删除后仍有管线: %v", got)
+	}
+	if got := len(Entries("kept", "", LevelDebug, 0)); got != 1 {
+		t.Fatalf("其他摄像头的日志被删除: %d 行", got)
+	}
+	Remove("kept")
+}
+
+func TestIsPipeline(t *testing.T) {
+	for _, name := range PipelineNames() {
+		if !IsPipeline(name) {
+			t.Errorf("IsPipeline(%q) = false", name)
+		}
+	}
+	for _, name := range []string{"", "record", "Capture", "webrtc"} {
+		if IsPipeline(name) {
+			t.Errorf("IsPipeline(%q) = true", name)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"home-monitor/internal/audio"
+	"home-monitor/internal/capture"
+)
+
+// 实时电平推送间隔（毫秒）的默认值和范围，间隔内的音频块合并为一个点
+const (
+	defaultLevelInterval = 100
+	minLevelInterval     = 20
+	maxLevelInterval     = 1000
+)
+
+// AudioHandler 音频电平 API 处理器
+type AudioHandler struct {
+	audioManager   *audio.Manager
+	captureManager *capture.Manager
+}
+
+// NewAudioHandler 创建音频电平处理器
+func NewAudioHandler(audioManager *audio.Manager, capManager *capture.Manager) *AudioHandler {
+	return &AudioHandler{
+		audioManager:   audioManager,
+		captureManager: capManager,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *AudioHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/cameras/:id/audio/level", h.GetLevel)
+	r.GET("/cameras/:id/audio/level/stream", h.StreamLevel)
+	r.GET("/cameras/:id/audio/events", h.GetEvents)
+}
+
+// checkAudio 检查摄像头存在且启用了音频，否则已写入响应
+func (h *AudioHandler) checkAudio(c *gin.Context) bool {
+	cap, err := h.captureManager.GetCapturer(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return false
+	}
+	if !cap.HasAudio() {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "摄像头未启用音频",
+		})
+		return false
+	}
+	return true
+}
+
+// GetLevel 获取摄像头的当前音频电平和最近的电平历史
+// GET /api/cameras/:id/audio/level?seconds=10
+// seconds 为历史窗口（1-10 秒），每个点对应一个 20ms 音频块
+func (h *AudioHandler) GetLevel(c *gin.Context) {
+	if !h.checkAudio(c) {
+		return
+	}
+
+	seconds := 10
+	if s := c.Query("seconds"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			seconds = min(v, 10)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.audioManager.Snapshot(c.Param("id"), time.Duration(seconds)*time.Second),
+	})
+}
+
+// StreamLevel 通过 SSE 实时推送音频电平，每个间隔一个 level 事件（RMS 按能量平均，峰值取最大）
+// GET /api/cameras/:id/audio/level/stream?interval=100
+// interval 为推送间隔毫秒数（20-1000），采集器重启期间连接保持，间隔内没有音频时不推送
+func (h *AudioHandler) StreamLevel(c *gin.Context) {
+	if !h.checkAudio(c) {
+		return
+	}
+
+	interval := defaultLevelInterval
+	if s := c.Query("interval"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil {
+			interval = max(minLevelInterval, min(v, maxLevelInterval))
+		}
+	}
+
+	levels, unsubscribe := h.audioManager.Subscribe(c.Param("id"))
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+	defer ticker.Stop()
+
+	var pending []audio.Level
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case level := <-levels:
+			pending = append(pending, level)
+		case <-ticker.C:
+			if len(pending) == 0 {
+				continue
+			}
+			c.SSEvent("level", audio.Combine(pending))
+			c.Writer.Flush()
+			pending = pending[:0]
+		}
+	}
+}
+
+// GetEvents 获取摄像头最近的音频检测事件（倒序）
+// GET /api/cameras/:id/audio/events?limit=50
+func (h *AudioHandler) GetEvents(c *gin.Context) {
+	if !h.checkAudio(c) {
+		return
+	}
+
+	limit := 50
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 {
+			limit = v
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.audioManager.Events(c.Param("id"), limit),
+	})
+}
@@ -0,0 +1,234 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"home-monitor/internal/capture"
+	"home-monitor/internal/config"
+	"home-monitor/internal/devices"
+)
+
+// CreateCamera 添加摄像头并写入配置文件，启用时立即启动
+// POST /api/cameras
+func (h *Handler) CreateCamera(c *gin.Context) {
+	cfg, ok := h.bindCameraConfig(c, "")
+	if !ok {
+		return
+	}
+
+	if _, err := h.captureManager.GetCapturer(cfg.ID); err == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "摄像头 " + cfg.ID + " 已存在",
+		})
+		return
+	}
+
+	if err := h.configStore.AddCamera(cfg); err != nil {
+		h.configStoreError(c, err)
+		return
+	}
+
+	info, err := h.applyCameraConfig(cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "配置已保存，但启动失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    info,
+	})
+}
+
+// UpdateCamera 更新摄像头配置并写入配置文件，运行中的采集器按新配置重建
+// PUT /api/cameras/:id
+func (h *Handler) UpdateCamera(c *gin.Context) {
+	id := c.Param("id")
+	cfg, ok := h.bindCameraConfig(c, id)
+	if !ok {
+		return
+	}
+
+	if err := h.configStore.UpdateCamera(cfg); err != nil {
+		h.configStoreError(c, err)
+		return
+	}
+
+	info, err := h.applyCameraConfig(cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "配置已保存，但应用失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    info,
+	})
+}
+
+// DeleteCamera 停止并删除摄像头，同时从配置文件移除
+// DELETE /api/cameras/:id
+func (h *Handler) DeleteCamera(c *gin.Context) {
+	if h.configStore == nil {
+		h.configStoreError(c, nil)
+		return
+	}
+
+	id := c.Param("id")
+	_, capErr := h.captureManager.GetCapturer(id)
+
+	err := h.configStore.DeleteCamera(id)
+	if err != nil && !(errors.Is(err, config.ErrCameraNotFound) && capErr == nil) {
+		h.configStoreError(c, err)
+		return
+	}
+
+	if capErr == nil {
+		if err := h.captureManager.RemoveCapturer(id); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "摄像头已删除",
+	})
+}
+
+// bindCameraConfig 解析并校验请求中的摄像头配置，失败时已写入响应
+// pathID 非空时以路径参数为准，请求体中的 id 必须为空或一致
+// 返回用户提交的原样配置（按设置默认值后的副本校验），写入配置文件时不带入默认值
+func (h *Handler) bindCameraConfig(c *gin.Context, pathID string) (config.CameraConfig, bool) {
+	var cfg config.CameraConfig
+
+	if h.configStore == nil {
+		h.configStoreError(c, nil)
+		return cfg, false
+	}
+
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数: " + err.Error(),
+		})
+		return cfg, false
+	}
+
+	if pathID != "" {
+		if cfg.ID != "" && cfg.ID != pathID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "不能修改摄像头 ID",
+			})
+			return cfg, false
+		}
+		cfg.ID = pathID
+
+		// 未修改的密码、令牌按 API 输出的占位符提交，还原为配置文件中的值（禁用的摄像头没有采集器）
+		if existing, err := h.configStore.GetCamera(pathID); err == nil {
+			cfg.RestoreSecrets(existing)
+		}
+	}
+	if cfg.Password == config.SecretMask || cfg.PushToken == config.SecretMask {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "密码或推送令牌为占位符，请重新填写",
+		})
+		return cfg, false
+	}
+
+	defaulted := config.WithCameraDefaults(cfg)
+	err := defaulted.Validate()
+	if err == nil {
+		err = h.encoders.ValidateCamera(defaulted)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return cfg, false
+	}
+
+	// 本地设备按实际支持的分辨率和帧率校验
+	if err := devices.ValidateCamera(defaulted); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return cfg, false
+	}
+	return cfg, true
+}
+
+// applyCameraConfig 将已保存的配置设置默认值后应用到运行中的采集器
+// 启用的摄像头创建或重建采集器，禁用的摄像头停止并移除采集器
+func (h *Handler) applyCameraConfig(cfg config.CameraConfig) (CameraInfo, error) {
+	cfg = config.WithCameraDefaults(cfg)
+	_, err := h.captureManager.GetCapturer(cfg.ID)
+	exists := err == nil
+
+	if !cfg.Enabled {
+		if exists {
+			if err := h.captureManager.RemoveCapturer(cfg.ID); err != nil {
+				return CameraInfo{}, err
+			}
+		}
+		return CameraInfo{
+			ID:     cfg.ID,
+			Name:   cfg.Name,
+			Config: cfg.Masked(),
+			Status: capture.Status{State: capture.StateStopped, LastFrameAge: -1},
+		}, nil
+	}
+
+	var cap capture.AVCapturer
+	if exists {
+		cap, err = h.captureManager.ReplaceCapturer(cfg)
+		if err != nil {
+			return CameraInfo{}, err
+		}
+	} else {
+		if cap, err = h.captureManager.AddCapturer(cfg); err != nil {
+			return CameraInfo{}, err
+		}
+		if err := h.captureManager.StartCapturer(cfg.ID); err != nil {
+			return CameraInfo{}, err
+		}
+	}
+	return newCameraInfo(cap), nil
+}
+
+// configStoreError 按错误类型返回配置存储错误
+func (h *Handler) configStoreError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	message := "未配置配置文件存储"
+	if err != nil {
+		message = err.Error()
+		switch {
+		case errors.Is(err, config.ErrCameraExists):
+			status = http.StatusConflict
+		case errors.Is(err, config.ErrCameraNotFound):
+			status = http.StatusNotFound
+		}
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   message,
+	})
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"home-monitor/internal/devices"
+)
+
+// DeviceHandler 本地设备发现 API 处理器
+type DeviceHandler struct{}
+
+// NewDeviceHandler 创建设备发现处理器
+func NewDeviceHandler() *DeviceHandler {
+	return &DeviceHandler{}
+}
+
+// RegisterRoutes 注册路由
+func (h *DeviceHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/devices", h.ListDevices)
+}
+
+// ListDevices 列出本地视频/音频采集设备
+// GET /api/devices
+func (h *DeviceHandler) ListDevices(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    devices.List(),
+	})
+}
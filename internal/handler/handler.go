@@ -3,12 +3,15 @@ package handler
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 
 	"home-monitor/internal/capture"
+	"home-monitor/internal/config"
 	"home-monitor/internal/storage"
 	"home-monitor/internal/stream"
 )
@@ -21,6 +24,10 @@ type Handler struct {
 	upgrader       websocket.Upgrader
 	// 预览服务配置（用于主页显示链接）
 	previewConfig *PreviewDisplayConfig
+	// 配置文件存储（摄像头增删改持久化）
+	configStore *config.Store
+	// 编码档位（校验摄像头引用的档位）
+	encoders config.EncoderProfiles
 }
 
 // PreviewDisplayConfig 预览显示配置
@@ -53,12 +60,44 @@ func (h *Handler) SetPreviewConfig(cfg *PreviewDisplayConfig) {
 	h.previewConfig = cfg
 }
 
+// SetConfigStore 设置配置文件存储
+func (h *Handler) SetConfigStore(store *config.Store) {
+	h.configStore = store
+}
+
+// SetEncoderProfiles 设置编码档位
+func (h *Handler) SetEncoderProfiles(encoders config.EncoderProfiles) {
+	h.encoders = encoders
+}
+
 // CameraInfo 摄像头信息
 type CameraInfo struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	IsRunning bool   `json:"is_running"`
-	HasAudio  bool   `json:"has_audio"`
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	IsRunning bool           `json:"is_running"`
+	HasAudio  bool           `json:"has_audio"`
+	Profiles  []string       `json:"profiles"` // 可用的预览档位，main 在最前
+	Width     int            `json:"width"`    // 主预览画面尺寸（已按旋转、裁剪换算），未知时为 0
+	Height    int            `json:"height"`
+	Status    capture.Status `json:"status"`
+	// 摄像头配置，密码、推送令牌和 URL 中的凭据已替换为占位符
+	Config config.CameraConfig `json:"config"`
+}
+
+// newCameraInfo 根据采集器生成摄像头信息
+func newCameraInfo(cap capture.AVCapturer) CameraInfo {
+	width, height := cap.VideoSize()
+	return CameraInfo{
+		ID:        cap.GetID(),
+		Name:      cap.GetName(),
+		IsRunning: cap.IsRunning(),
+		HasAudio:  cap.HasAudio(),
+		Profiles:  cap.Profiles(),
+		Width:     width,
+		Height:    height,
+		Status:    cap.GetStatus(),
+		Config:    cap.GetConfig().Masked(),
+	}
 }
 
 // GetCameras 获取所有摄像头
@@ -66,12 +105,7 @@ func (h *Handler) GetCameras(c *gin.Context) {
 	capturers := h.captureManager.GetAllCapturers()
 	var infos []CameraInfo
 	for _, cap := range capturers {
-		infos = append(infos, CameraInfo{
-			ID:        cap.GetID(),
-			Name:      cap.GetName(),
-			IsRunning: cap.IsRunning(),
-			HasAudio:  cap.HasAudio(),
-		})
+		infos = append(infos, newCameraInfo(cap))
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -92,12 +126,68 @@ func (h *Handler) GetCamera(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data": CameraInfo{
-			ID:        cap.GetID(),
-			Name:      cap.GetName(),
-			IsRunning: cap.IsRunning(),
-			HasAudio:  cap.HasAudio(),
-		},
+		"data":    newCameraInfo(cap),
+	})
+}
+
+// GetSubscribers 获取摄像头帧/音频订阅者的投递统计（排队深度、投递与丢弃计数）
+func (h *Handler) GetSubscribers(c *gin.Context) {
+	id := c.Param("id")
+	cap, err := h.captureManager.GetCapturer(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    cap.SubscriberStats(),
+	})
+}
+
+// StartCamera 启动单个摄像头
+// POST /api/cameras/:id/start
+func (h *Handler) StartCamera(c *gin.Context) {
+	h.controlCamera(c, h.captureManager.StartCapturer)
+}
+
+// StopCamera 停止单个摄像头（同时拆除其 RTMP/HLS/WebRTC 输出）
+// POST /api/cameras/:id/stop
+func (h *Handler) StopCamera(c *gin.Context) {
+	h.controlCamera(c, h.captureManager.StopCapturer)
+}
+
+// RestartCamera 重启单个摄像头（重启后恢复其 RTMP/HLS/WebRTC 输出）
+// POST /api/cameras/:id/restart
+func (h *Handler) RestartCamera(c *gin.Context) {
+	h.controlCamera(c, h.captureManager.RestartCapturer)
+}
+
+// controlCamera 执行启停操作并返回最新摄像头信息
+func (h *Handler) controlCamera(c *gin.Context, op func(id string) error) {
+	id := c.Param("id")
+	cap, err := h.captureManager.GetCapturer(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := op(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    newCameraInfo(cap),
 	})
 }
 
@@ -121,14 +211,24 @@ func (h *Handler) StreamMJPEG(c *gin.Context) {
 		return
 	}
 
+	profile, ok := requestProfile(c, cap)
+	if !ok {
+		return
+	}
+
 	c.Header("Content-Type", "multipart/x-mixed-replace; boundary=frame")
 	c.Header("Cache-Control", "no-cache")
 	c.Header("Connection", "keep-alive")
 
 	// 订阅帧通道
+	// 观看者只关心最新画面，跟不上时直接跳到最新帧
 	subID := fmt.Sprintf("mjpeg_%d", time.Now().UnixNano())
-	frameChannel := cap.SubscribeFrames(subID)
-	defer cap.UnsubscribeFrames(subID)
+	frameChannel := cap.SubscribeFrameRefs(subID,
+		capture.WithPolicy(capture.LatestOnly),
+		capture.WithProfile(profile),
+		capture.WithLabel("mjpeg "+c.ClientIP()),
+	)
+	defer cap.UnsubscribeFrameRefs(subID)
 
 	for {
 		select {
@@ -140,8 +240,10 @@ func (h *Handler) StreamMJPEG(c *gin.Context) {
 			}
 			c.Writer.Write([]byte("--frame\r\n"))
 			c.Writer.Write([]byte("Content-Type: image/jpeg\r\n"))
-			c.Writer.Write([]byte(fmt.Sprintf("Content-Length: %d\r\n\r\n", len(frame))))
-			c.Writer.Write(frame)
+			c.Writer.Write([]byte(frameHeaders(frame.Meta())))
+			c.Writer.Write([]byte(fmt.Sprintf("Content-Length: %d\r\n\r\n", frame.Len())))
+			c.Writer.Write(frame.Bytes())
+			frame.Release()
 			c.Writer.Write([]byte("\r\n"))
 			c.Writer.Flush()
 		}
@@ -160,6 +262,11 @@ func (h *Handler) StreamWebSocket(c *gin.Context) {
 		return
 	}
 
+	profile, ok := requestProfile(c, cap)
+	if !ok {
+		return
+	}
+
 	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -177,8 +284,12 @@ func (h *Handler) StreamWebSocket(c *gin.Context) {
 
 	// 订阅帧通道
 	subID := fmt.Sprintf("websocket_%d", time.Now().UnixNano())
-	frameChannel := cap.SubscribeFrames(subID)
-	defer cap.UnsubscribeFrames(subID)
+	frameChannel := cap.SubscribeFrameRefs(subID,
+		capture.WithPolicy(capture.LatestOnly),
+		capture.WithProfile(profile),
+		capture.WithLabel("websocket "+c.ClientIP()),
+	)
+	defer cap.UnsubscribeFrameRefs(subID)
 
 	for {
 		select {
@@ -188,13 +299,34 @@ func (h *Handler) StreamWebSocket(c *gin.Context) {
 			if !ok {
 				return
 			}
-			if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+			err := conn.WriteMessage(websocket.BinaryMessage, frame.Bytes())
+			frame.Release()
+			if err != nil {
 				return
 			}
 		}
 	}
 }
 
+// requestProfile 读取 ?profile= 预览档位参数，默认 main；档位不存在时返回 400
+func requestProfile(c *gin.Context, cap capture.AVCapturer) (string, bool) {
+	profile := c.DefaultQuery("profile", capture.MainProfile)
+	if !cap.HasProfile(profile) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("预览档位不存在: %s（可用: %s）", profile, strings.Join(cap.Profiles(), ", ")),
+		})
+		return "", false
+	}
+	return profile, true
+}
+
+// frameHeaders 生成 MJPEG 分段中的帧元数据头，供客户端检测丢帧和计算延迟
+func frameHeaders(meta capture.FrameMeta) string {
+	return fmt.Sprintf("X-Frame-Seq: %d\r\nX-Frame-Timestamp: %s\r\n",
+		meta.Seq, meta.CapturedAt.UTC().Format(time.RFC3339Nano))
+}
+
 // GetSnapshot 获取快照
 func (h *Handler) GetSnapshot(c *gin.Context) {
 	id := c.Param("id")
@@ -207,7 +339,12 @@ func (h *Handler) GetSnapshot(c *gin.Context) {
 		return
 	}
 
-	frame, err := cap.GetFrame()
+	profile, ok := requestProfile(c, cap)
+	if !ok {
+		return
+	}
+
+	frame, err := cap.GetProfileFrameRef(profile)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -215,9 +352,13 @@ func (h *Handler) GetSnapshot(c *gin.Context) {
 		})
 		return
 	}
+	defer frame.Release()
 
+	meta := frame.Meta()
 	c.Header("Content-Type", "image/jpeg")
-	c.Writer.Write(frame)
+	c.Header("X-Frame-Seq", strconv.FormatUint(meta.Seq, 10))
+	c.Header("X-Frame-Timestamp", meta.CapturedAt.UTC().Format(time.RFC3339Nano))
+	c.Writer.Write(frame.Bytes())
 }
 
 // GetRecordings 获取录像列表
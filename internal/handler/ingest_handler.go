@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"home-monitor/internal/capture"
+)
+
+// 单帧最大上传字节数
+const maxIngestFrameSize = 8 * 1024 * 1024
+
+// IngestHandler 帧推送 API 处理器
+// 供无法被拉流的设备（ESP32、旧手机脚本等）主动上传 JPEG 帧
+type IngestHandler struct {
+	captureManager *capture.Manager
+	upgrader       websocket.Upgrader
+}
+
+// NewIngestHandler 创建帧推送处理器
+func NewIngestHandler(captureManager *capture.Manager) *IngestHandler {
+	return &IngestHandler{
+		captureManager: captureManager,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return true
+			},
+			ReadBufferSize:  64 * 1024,
+			WriteBufferSize: 1024,
+		},
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *IngestHandler) RegisterRoutes(r *gin.RouterGroup) {
+	ingest := r.Group("/ingest")
+	{
+		ingest.POST("/:camera_id/frame", h.PushFrame)
+		ingest.GET("/:camera_id/ws", h.PushWebSocket)
+	}
+}
+
+// PushFrame 上传单帧 JPEG
+// POST /api/ingest/:camera_id/frame
+// 请求体为 image/jpeg 原始数据，或 multipart/form-data 中的第一个文件
+// 认证: Authorization: Bearer <push_token> 或 ?token=<push_token>
+func (h *IngestHandler) PushFrame(c *gin.Context) {
+	pusher, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	frame, err := readIngestFrame(c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := pusher.PushFrame(frame); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// PushWebSocket 通过 WebSocket 持续推送帧，每条二进制消息为一帧 JPEG
+// GET /api/ingest/:camera_id/ws
+func (h *IngestHandler) PushWebSocket(c *gin.Context) {
+	pusher, ok := h.authorize(c)
+	if !ok {
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(maxIngestFrameSize)
+
+	for {
+		msgType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if msgType != websocket.BinaryMessage {
+			continue
+		}
+		if err := pusher.PushFrame(data); err != nil {
+			conn.WriteJSON(gin.H{"error": err.Error()})
+		}
+	}
+}
+
+// authorize 查找推送型摄像头并校验令牌，失败时已写入响应
+func (h *IngestHandler) authorize(c *gin.Context) (capture.FramePusher, bool) {
+	cameraID := c.Param("camera_id")
+	cap, err := h.captureManager.GetCapturer(cameraID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	pusher, ok := cap.(capture.FramePusher)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "该摄像头不是推送类型",
+		})
+		return nil, false
+	}
+
+	expected := cap.GetConfig().PushToken
+	if expected == "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "摄像头未配置 push_token",
+		})
+		return nil, false
+	}
+
+	token := c.Query("token")
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "推送令牌无效",
+		})
+		return nil, false
+	}
+
+	return pusher, true
+}
+
+// readIngestFrame 读取请求中的帧数据
+func readIngestFrame(r *http.Request) ([]byte, error) {
+	body := http.MaxBytesReader(nil, r.Body, maxIngestFrameSize)
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				return nil, fmt.Errorf("表单中没有帧文件")
+			}
+			if part.FileName() != "" {
+				defer part.Close()
+				return io.ReadAll(part)
+			}
+			part.Close()
+		}
+	}
+
+	return io.ReadAll(body)
+}
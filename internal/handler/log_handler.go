@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"home-monitor/internal/capture"
+	"home-monitor/internal/ffmpeglog"
+)
+
+// 日志查询默认和最大返回行数
+const (
+	defaultLogLimit = 200
+	maxLogLimit     = 2000
+)
+
+// LogHandler 摄像头 FFmpeg 日志 API 处理器
+type LogHandler struct {
+	captureManager *capture.Manager
+}
+
+// NewLogHandler 创建日志处理器
+func NewLogHandler(capManager *capture.Manager) *LogHandler {
+	return &LogHandler{
+		captureManager: capManager,
+	}
+}
+
+// RegisterRoutes 注册路由
+func (h *LogHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/cameras/:id/logs", h.GetLogs)
+	r.GET("/cameras/:id/logs/stream", h.StreamLogs)
+}
+
+// logFilter 日志查询条件
+type logFilter struct {
+	pipeline string
+	level    ffmpeglog.Level
+}
+
+// match 是否满足查询条件
+func (f logFilter) match(entry ffmpeglog.Entry) bool {
+	return (f.pipeline == "" || entry.Pipeline == f.pipeline) && entry.Level.AtLeast(f.level)
+}
+
+// parseLogFilter 解析 pipeline、level 参数，摄像头不存在或参数无效时已写入响应
+// FFmpeg 类摄像头的分段录像由采集进程输出，pipeline=recording 按 capture 查询
+func (h *LogHandler) parseLogFilter(c *gin.Context) (logFilter, bool) {
+	id := c.Param("id")
+	cap, err := h.captureManager.GetCapturer(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return logFilter{}, false
+	}
+
+	filter := logFilter{
+		pipeline: c.Query("pipeline"),
+		level:    ffmpeglog.LevelDebug,
+	}
+	if filter.pipeline != "" && !ffmpeglog.IsPipeline(filter.pipeline) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error": fmt.Sprintf("无效的管线: %s（%s）",
+				filter.pipeline, strings.Join(ffmpeglog.PipelineNames(), ", ")),
+		})
+		return logFilter{}, false
+	}
+	if filter.pipeline == ffmpeglog.PipelineRecording && cap.GetConfig().Type != "push" {
+		filter.pipeline = ffmpeglog.PipelineCapture
+	}
+	if s := c.Query("level"); s != "" {
+		level, ok := ffmpeglog.ParseLevel(s)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("无效的日志级别: %s（error, warning, info, debug）", s),
+			})
+			return logFilter{}, false
+		}
+		filter.level = level
+	}
+	return filter, true
+}
+
+// GetLogs 获取摄像头 FFmpeg 进程的最近日志
+// GET /api/cameras/:id/logs?pipeline=capture&level=warning&limit=200
+// pipeline 为空时合并所有管线（capture, recording, hls, rtmp, webrtc_video, webrtc_audio），
+// 非推送型摄像头的 recording 即 capture（录像由采集进程输出），未知管线返回 400
+func (h *LogHandler) GetLogs(c *gin.Context) {
+	filter, ok := h.parseLogFilter(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultLogLimit
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 {
+			limit = min(v, maxLogLimit)
+		}
+	}
+
+	id := c.Param("id")
+	entries := ffmpeglog.Entries(id, filter.pipeline, filter.level, limit)
+	if entries == nil {
+		entries = []ffmpeglog.Entry{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"data":      entries,
+		"pipelines": ffmpeglog.Pipelines(id),
+	})
+}
+
+// StreamLogs 通过 SSE 实时推送摄像头 FFmpeg 日志，每行一个 log 事件
+// GET /api/cameras/:id/logs/stream?pipeline=capture&level=warning
+func (h *LogHandler) StreamLogs(c *gin.Context) {
+	filter, ok := h.parseLogFilter(c)
+	if !ok {
+		return
+	}
+
+	entries, unsubscribe := ffmpeglog.Subscribe(c.Param("id"))
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case entry := <-entries:
+			if !filter.match(entry) {
+				continue
+			}
+			c.SSEvent("log", entry)
+			c.Writer.Flush()
+		}
+	}
+}
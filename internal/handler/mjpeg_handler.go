@@ -14,16 +14,15 @@ import (
 // MJPEGHandler MJPEG 独立服务处理器
 type MJPEGHandler struct {
 	capManager *capture.Manager
-	quality    int
 	mainPort   int
 	mjpegPort  int
 }
 
 // NewMJPEGHandler 创建 MJPEG 处理器
-func NewMJPEGHandler(capManager *capture.Manager, quality, mainPort, mjpegPort int) *MJPEGHandler {
+// 预览质量在采集端生效，见 capture.Manager.SetPreviewQuality
+func NewMJPEGHandler(capManager *capture.Manager, mainPort, mjpegPort int) *MJPEGHandler {
 	return &MJPEGHandler{
 		capManager: capManager,
-		quality:    quality,
 		mainPort:   mainPort,
 		mjpegPort:  mjpegPort,
 	}
@@ -53,6 +52,11 @@ func (h *MJPEGHandler) StreamMJPEG(c *gin.Context) {
 		return
 	}
 
+	profile, ok := requestProfile(c, capturer)
+	if !ok {
+		return
+	}
+
 	// 设置 MJPEG 头
 	c.Header("Content-Type", "multipart/x-mixed-replace; boundary=frame")
 	c.Header("Cache-Control", "no-cache")
@@ -60,8 +64,12 @@ func (h *MJPEGHandler) StreamMJPEG(c *gin.Context) {
 
 	// 订阅帧 - 生成唯一订阅ID
 	subID := fmt.Sprintf("mjpeg-%s-%d", cameraID, time.Now().UnixNano())
-	frameCh := capturer.SubscribeFrames(subID)
-	defer capturer.UnsubscribeFrames(subID)
+	frameCh := capturer.SubscribeFrameRefs(subID,
+		capture.WithPolicy(capture.LatestOnly),
+		capture.WithProfile(profile),
+		capture.WithLabel("mjpeg "+c.ClientIP()),
+	)
+	defer capturer.UnsubscribeFrameRefs(subID)
 
 	for {
 		select {
@@ -73,8 +81,10 @@ func (h *MJPEGHandler) StreamMJPEG(c *gin.Context) {
 			// 写入 MJPEG 边界和帧
 			fmt.Fprintf(c.Writer, "--frame\r\n")
 			fmt.Fprintf(c.Writer, "Content-Type: image/jpeg\r\n")
-			fmt.Fprintf(c.Writer, "Content-Length: %d\r\n\r\n", len(frame))
-			c.Writer.Write(frame)
+			fmt.Fprint(c.Writer, frameHeaders(frame.Meta()))
+			fmt.Fprintf(c.Writer, "Content-Length: %d\r\n\r\n", frame.Len())
+			c.Writer.Write(frame.Bytes())
+			frame.Release()
 			fmt.Fprintf(c.Writer, "\r\n")
 			c.Writer.Flush()
 
@@ -98,13 +108,19 @@ func (h *MJPEGHandler) GetSnapshot(c *gin.Context) {
 		return
 	}
 
-	frame, err := capturer.GetFrame()
-	if err != nil || frame == nil {
+	profile, ok := requestProfile(c, capturer)
+	if !ok {
+		return
+	}
+
+	frame, err := capturer.GetProfileFrameRef(profile)
+	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "暂无画面"})
 		return
 	}
+	defer frame.Release()
 
-	c.Data(http.StatusOK, "image/jpeg", frame)
+	c.Data(http.StatusOK, "image/jpeg", frame.Bytes())
 }
 
 // SetupMJPEGRoutes 设置 MJPEG 服务路由
@@ -112,9 +128,9 @@ func SetupMJPEGRoutes(router *gin.Engine, h *MJPEGHandler) {
 	// 首页
 	router.GET("/", h.Index)
 
-	// MJPEG 流
+	// MJPEG 流（?profile= 选择预览档位，默认 main）
 	router.GET("/stream/:id/mjpeg", h.StreamMJPEG)
 
-	// 快照
+	// 快照（?profile= 同上）
 	router.GET("/snapshot/:id", h.GetSnapshot)
 }
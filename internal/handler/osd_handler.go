@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+
+	"home-monitor/internal/config"
+)
+
+// osdTextRequest OSD 动态文本请求体
+type osdTextRequest struct {
+	Text string `json:"text"`
+}
+
+// GetOSD 获取摄像头的 OSD 配置和当前动态文本
+// GET /api/cameras/:id/osd
+func (h *Handler) GetOSD(c *gin.Context) {
+	id := c.Param("id")
+	cap, err := h.captureManager.GetCapturer(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"config": cap.GetConfig().OSD,
+			"text":   h.captureManager.OSDText(id),
+		},
+	})
+}
+
+// SetOSDText 设置 OSD 动态文本（如布防状态 "ARMED"），立即叠加到画面，空字符串表示清除
+// 动态文本不写入配置文件，摄像头配置更新后保留，服务重启后清空
+// PUT /api/cameras/:id/osd/text
+func (h *Handler) SetOSDText(c *gin.Context) {
+	var req osdTextRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+	if len(req.Text) > config.MaxOSDTextLength || strings.ContainsFunc(req.Text, unicode.IsControl) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("OSD 文本不能包含控制字符，最多 %d 字节", config.MaxOSDTextLength),
+		})
+		return
+	}
+
+	id := c.Param("id")
+	if _, err := h.captureManager.GetCapturer(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if err := h.captureManager.SetOSDText(id, req.Text); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"text": req.Text,
+		},
+	})
+}
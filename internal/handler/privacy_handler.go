@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"home-monitor/internal/capture"
+	"home-monitor/internal/config"
+)
+
+// privacyMasksRequest 隐私遮挡区域请求体
+type privacyMasksRequest struct {
+	Masks []config.PrivacyMask `json:"masks"`
+}
+
+// GetPrivacyMasks 获取摄像头的隐私遮挡区域
+// GET /api/cameras/:id/privacy-masks
+func (h *Handler) GetPrivacyMasks(c *gin.Context) {
+	cfg, ok := h.cameraConfig(c)
+	if !ok {
+		return
+	}
+
+	masks := cfg.PrivacyMasks
+	if masks == nil {
+		masks = []config.PrivacyMask{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    masks,
+	})
+}
+
+// UpdatePrivacyMasks 替换摄像头的隐私遮挡区域并写入配置文件，运行中的采集器按新配置重建
+// PUT /api/cameras/:id/privacy-masks
+// 请求体: {"masks": [{"name": "邻居窗户", "points": [[0.1, 0.1], [0.3, 0.1], [0.3, 0.4]]}]}，空列表表示清除
+func (h *Handler) UpdatePrivacyMasks(c *gin.Context) {
+	cfg, ok := h.cameraConfig(c)
+	if !ok {
+		return
+	}
+
+	var req privacyMasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+
+	cfg.PrivacyMasks = req.Masks
+	defaulted := config.WithCameraDefaults(cfg)
+	if err := defaulted.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	if err := h.configStore.UpdateCamera(cfg); err != nil {
+		h.configStoreError(c, err)
+		return
+	}
+
+	info, err := h.applyCameraConfig(cfg)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "配置已保存，但应用失败: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    info,
+	})
+}
+
+// PreviewPrivacyMasks 在当前快照上以半透明红色标出遮挡区域，返回 JPEG
+// POST /api/cameras/:id/privacy-masks/preview
+// 请求体同 UpdatePrivacyMasks，用于保存前预览；请求体为空时标出已配置的区域
+func (h *Handler) PreviewPrivacyMasks(c *gin.Context) {
+	cap, err := h.captureManager.GetCapturer(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	masks := cap.GetConfig().PrivacyMasks
+	var req privacyMasksRequest
+	if err := c.ShouldBindJSON(&req); err == nil {
+		masks = req.Masks
+	} else if !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+	if err := config.ValidatePrivacyMasks(masks); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	frame, err := cap.GetFrameRef()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	defer frame.Release()
+
+	preview, err := capture.PreviewPrivacyMasks(frame.Bytes(), masks)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.Data(http.StatusOK, "image/jpeg", preview)
+}
+
+// cameraConfig 从配置文件读取路径参数指定的摄像头配置，失败时已写入响应
+func (h *Handler) cameraConfig(c *gin.Context) (config.CameraConfig, bool) {
+	if h.configStore == nil {
+		h.configStoreError(c, nil)
+		return config.CameraConfig{}, false
+	}
+	cfg, err := h.configStore.GetCamera(c.Param("id"))
+	if err != nil {
+		h.configStoreError(c, err)
+		return config.CameraConfig{}, false
+	}
+	return cfg, true
+}
+
+// privacyModeRequest 隐私模式请求体
+type privacyModeRequest struct {
+	Mode string `json:"mode"`
+}
+
+// GetPrivacyMode 获取摄像头的隐私模式状态
+// GET /api/cameras/:id/privacy
+func (h *Handler) GetPrivacyMode(c *gin.Context) {
+	cap, err := h.captureManager.GetCapturer(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    cap.PrivacyStatus(),
+	})
+}
+
+// SetPrivacyMode 切换摄像头的隐私模式：停止录像，所有预览输出替换为占位画面，订阅者保持连接
+// on/off 为手动开关并覆盖计划时段，auto 恢复按 privacy_schedules 切换；设置不写入配置文件，服务重启后为 auto
+// POST /api/cameras/:id/privacy
+// 请求体: {"mode": "on"}
+func (h *Handler) SetPrivacyMode(c *gin.Context) {
+	var req privacyModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "无效的请求参数: " + err.Error(),
+		})
+		return
+	}
+	mode, ok := capture.ParsePrivacyMode(req.Mode)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("无效的隐私模式: %q（on, off, auto）", req.Mode),
+		})
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.captureManager.SetPrivacyMode(id, mode); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	cap, err := h.captureManager.GetCapturer(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    cap.PrivacyStatus(),
+	})
+}
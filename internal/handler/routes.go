@@ -32,8 +32,22 @@ func SetupRoutes(router *gin.Engine, handler *Handler, webrtcHandler *WebRTCHand
 		cameras := api.Group("/cameras")
 		{
 			cameras.GET("", handler.GetCameras)
+			cameras.POST("", handler.CreateCamera)
 			cameras.GET("/:id", handler.GetCamera)
+			cameras.PUT("/:id", handler.UpdateCamera)
+			cameras.DELETE("/:id", handler.DeleteCamera)
 			cameras.GET("/:id/snapshot", handler.GetSnapshot)
+			cameras.GET("/:id/subscribers", handler.GetSubscribers)
+			cameras.POST("/:id/start", handler.StartCamera)
+			cameras.POST("/:id/stop", handler.StopCamera)
+			cameras.POST("/:id/restart", handler.RestartCamera)
+			cameras.GET("/:id/privacy-masks", handler.GetPrivacyMasks)
+			cameras.PUT("/:id/privacy-masks", handler.UpdatePrivacyMasks)
+			cameras.POST("/:id/privacy-masks/preview", handler.PreviewPrivacyMasks)
+			cameras.GET("/:id/privacy", handler.GetPrivacyMode)
+			cameras.POST("/:id/privacy", handler.SetPrivacyMode)
+			cameras.GET("/:id/osd", handler.GetOSD)
+			cameras.PUT("/:id/osd/text", handler.SetOSDText)
 		}
 
 		// 流
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 )
@@ -63,6 +64,15 @@ type Alert struct {
 	Message  string    `json:"message"`
 	Value    string    `json:"value"`
 	Resolved bool      `json:"resolved"`
+	CameraID string    `json:"camera_id,omitempty"` // 摄像头相关告警（画面停滞、音频事件）
+}
+
+// CameraHealth 摄像头健康状态（用于画面停滞告警）
+type CameraHealth struct {
+	ID           string
+	Name         string
+	Stalled      bool
+	LastFrameAge time.Duration
 }
 
 // Monitor 性能监控器
@@ -85,6 +95,10 @@ type Monitor struct {
 	lastMemAlert       bool
 	lastGoroutineAlert bool
 
+	// 摄像头健康状态来源及上次停滞告警状态
+	cameraHealth    func() []CameraHealth
+	lastStallAlerts map[string]bool
+
 	mutex sync.RWMutex
 
 	ctx    context.Context
@@ -101,6 +115,7 @@ func NewMonitor() *Monitor {
 		alertsLimit:        100,
 		memThreshold:       512 * 1024 * 1024, // 512MB
 		goroutineThreshold: 1000,
+		lastStallAlerts:    make(map[string]bool),
 	}
 }
 
@@ -117,6 +132,13 @@ func (m *Monitor) SetThresholds(memMB int, goroutines int) {
 	}
 }
 
+// SetCameraHealthProvider 设置摄像头健康状态来源，每次采集时检查画面停滞
+func (m *Monitor) SetCameraHealthProvider(provider func() []CameraHealth) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.cameraHealth = provider
+}
+
 // Start 启动监控
 func (m *Monitor) Start(ctx context.Context) {
 	m.ctx, m.cancel = context.WithCancel(ctx)
@@ -166,6 +188,15 @@ func (m *Monitor) collect() {
 		NumGC:        memStats.NumGC,
 	}
 
+	// 在锁外获取摄像头状态，避免与采集器锁嵌套
+	m.mutex.RLock()
+	provider := m.cameraHealth
+	m.mutex.RUnlock()
+	var cameras []CameraHealth
+	if provider != nil {
+		cameras = provider()
+	}
+
 	m.mutex.Lock()
 
 	// 添加到历史
@@ -176,6 +207,7 @@ func (m *Monitor) collect() {
 
 	// 检查告警
 	m.checkAlerts(point, memStats)
+	m.checkCameraAlerts(cameras)
 
 	m.mutex.Unlock()
 
@@ -218,15 +250,65 @@ func (m *Monitor) checkAlerts(point HistoryPoint, memStats runtime.MemStats) {
 	}
 }
 
+// checkCameraAlerts 检查摄像头画面停滞告警
+// 已移除的摄像头不再出现在 cameras 中，清除其停滞状态，之后同 ID 的摄像头可以重新告警
+func (m *Monitor) checkCameraAlerts(cameras []CameraHealth) {
+	present := make(map[string]bool, len(cameras))
+	for _, cam := range cameras {
+		present[cam.ID] = true
+	}
+	for id := range m.lastStallAlerts {
+		if !present[id] {
+			delete(m.lastStallAlerts, id)
+		}
+	}
+
+	for _, cam := range cameras {
+		age := cam.LastFrameAge.Round(time.Second).String()
+		if cam.Stalled {
+			if !m.lastStallAlerts[cam.ID] {
+				m.recordAlert(Alert{
+					Type:     "camera_stall",
+					Message:  fmt.Sprintf("摄像头 %s (%s) 画面停滞，最近一帧在 %s 前", cam.Name, cam.ID, age),
+					Value:    age,
+					CameraID: cam.ID,
+				})
+				m.lastStallAlerts[cam.ID] = true
+			}
+		} else if m.lastStallAlerts[cam.ID] {
+			m.recordAlert(Alert{
+				Type:     "camera_stall_resolved",
+				Message:  fmt.Sprintf("摄像头 %s (%s) 画面恢复", cam.Name, cam.ID),
+				Value:    age,
+				CameraID: cam.ID,
+			})
+			delete(m.lastStallAlerts, cam.ID)
+		}
+	}
+}
+
+// ReportAlert 添加外部来源的告警（如音频事件），Time 为空时使用当前时间
+func (m *Monitor) ReportAlert(alert Alert) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.recordAlert(alert)
+}
+
 // addAlert 添加告警
 func (m *Monitor) addAlert(alertType, message, value string) {
-	alert := Alert{
-		Time:     time.Now(),
-		Type:     alertType,
-		Message:  message,
-		Value:    value,
-		Resolved: alertType == "memory_resolved" || alertType == "goroutine_resolved",
+	m.recordAlert(Alert{
+		Type:    alertType,
+		Message: message,
+		Value:   value,
+	})
+}
+
+// recordAlert 记录告警并输出日志，调用方持有 mutex
+func (m *Monitor) recordAlert(alert Alert) {
+	if alert.Time.IsZero() {
+		alert.Time = time.Now()
 	}
+	alert.Resolved = strings.HasSuffix(alert.Type, "_resolved")
 
 	m.alerts = append(m.alerts, alert)
 	if len(m.alerts) > m.alertsLimit {
@@ -235,9 +317,9 @@ func (m *Monitor) addAlert(alertType, message, value string) {
 
 	// 输出日志
 	if alert.Resolved {
-		log.Printf("✅ [告警恢复] %s", message)
+		log.Printf("✅ [告警恢复] %s", alert.Message)
 	} else {
-		log.Printf("⚠️ [告警] %s", message)
+		log.Printf("⚠️ [告警] %s", alert.Message)
 	}
 }
 
@@ -0,0 +1,40 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+// countAlerts 统计指定类型的告警数量
+func countAlerts(m *Monitor, alertType string) int {
+	count := 0
+	for _, alert := range m.GetAlerts(0) {
+		if alert.Type == alertType {
+			count++
+		}
+	}
+	return count
+}
+
+// TestStallAlertAfterCameraRemoved 停滞中被移除的摄像头，同 ID 重新添加后再次停滞时仍然告警
+func TestStallAlertAfterCameraRemoved(t *testing.T) {
+	m := NewMonitor()
+	stalled := []CameraHealth{{ID: "cam1", Name: "客厅", Stalled: true, LastFrameAge: 15 * time.Second}}
+
+	m.checkCameraAlerts(stalled)
+	m.checkCameraAlerts(stalled)
+	if got := countAlerts(m, "camera_stall"); got != 1 {
+		t.Fatalf("持续停滞告警 %d 次, want 1", got)
+	}
+
+	// 停滞期间被移除
+	m.checkCameraAlerts(nil)
+	if _, exists := m.lastStallAlerts["cam1"]; exists {
+		t.Fatal("移除后仍保留停滞状态")
+	}
+
+	m.checkCameraAlerts(stalled)
+	if got := countAlerts(m, "camera_stall"); got != 2 {
+		t.Fatalf("同 ID 的新摄像头停滞告警 %d 次, want 2", got)
+	}
+}
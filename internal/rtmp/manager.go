@@ -3,7 +3,9 @@ package rtmp
 import (
 	"context"
 	"fmt"
+	"log"
 	"sync"
+	"time"
 
 	"home-monitor/internal/capture"
 	"home-monitor/internal/config"
@@ -12,31 +14,55 @@ import (
 // Manager RTMP 推流管理器
 type Manager struct {
 	captureManager *capture.Manager
-	cameras        map[string]config.CameraConfig
+	encoders       config.EncoderProfiles
 	streamers      map[string]*Streamer
 	frameFeeds     map[string]context.CancelFunc
 
+	// 采集器重启期间暂停、重启后需恢复的推流地址
+	resume map[string]string
+
 	mutex sync.RWMutex
 	ctx   context.Context
 }
 
 // NewManager 创建 RTMP 管理器
-func NewManager(ctx context.Context, captureManager *capture.Manager, cameras []config.CameraConfig) *Manager {
+func NewManager(ctx context.Context, captureManager *capture.Manager, encoders config.EncoderProfiles) *Manager {
 	m := &Manager{
 		captureManager: captureManager,
-		cameras:        make(map[string]config.CameraConfig),
+		encoders:       encoders,
 		streamers:      make(map[string]*Streamer),
 		frameFeeds:     make(map[string]context.CancelFunc),
+		resume:         make(map[string]string),
 		ctx:            ctx,
 	}
 
-	for _, cam := range cameras {
-		if cam.Enabled {
-			m.cameras[cam.ID] = cam
+	captureManager.AddListener(m.handleCaptureEvent)
+	return m
+}
+
+// handleCaptureEvent 采集器停止时拆除推流，重启完成后按原地址恢复
+func (m *Manager) handleCaptureEvent(event capture.Event) {
+	switch event.Type {
+	case capture.EventStopping:
+		running, url := m.GetStreamStatus(event.CameraID)
+		m.StopStream(event.CameraID)
+		if running && event.Restarting {
+			m.mutex.Lock()
+			m.resume[event.CameraID] = url
+			m.mutex.Unlock()
+		}
+	case capture.EventStarted:
+		m.mutex.Lock()
+		url, resume := m.resume[event.CameraID]
+		delete(m.resume, event.CameraID)
+		m.mutex.Unlock()
+
+		if resume {
+			if err := m.StartStream(event.CameraID, url); err != nil {
+				log.Printf("恢复 RTMP 推流 %s 失败: %v", event.CameraID, err)
+			}
 		}
 	}
-
-	return m
 }
 
 // StartStream 启动 RTMP 推流
@@ -49,24 +75,20 @@ func (m *Manager) StartStream(cameraID, rtmpURL string) error {
 		return fmt.Errorf("摄像头 %s 已在推流中", cameraID)
 	}
 
-	// 获取摄像头配置
-	camConfig, exists := m.cameras[cameraID]
-	if !exists {
-		return fmt.Errorf("摄像头不存在: %s", cameraID)
-	}
-
 	// 获取采集器
 	capturer, err := m.captureManager.GetCapturer(cameraID)
 	if err != nil {
 		return fmt.Errorf("获取采集器失败: %w", err)
 	}
+	camConfig := capturer.GetConfig()
 
 	if !capturer.IsRunning() {
 		return fmt.Errorf("采集器未运行: %s", cameraID)
 	}
 
 	// 创建推流器
-	streamer := NewStreamer(cameraID, camConfig, rtmpURL)
+	encoder := m.encoders.Resolve(camConfig.Encoders.RTMP, config.EncoderRTMP)
+	streamer := NewStreamer(cameraID, camConfig, encoder, rtmpURL)
 
 	// 启动推流
 	if err := streamer.Start(m.ctx); err != nil {
@@ -77,11 +99,11 @@ func (m *Manager) StartStream(cameraID, rtmpURL string) error {
 	feedCtx, feedCancel := context.WithCancel(m.ctx)
 	m.frameFeeds[cameraID] = feedCancel
 
-	videoSubID := fmt.Sprintf("rtmp_video_%s", cameraID)
-	frameCh := capturer.SubscribeFrames(videoSubID)
+	videoSubID := fmt.Sprintf("rtmp_video_%s_%d", cameraID, time.Now().UnixNano())
+	frameCh := capturer.SubscribeFrameRefs(videoSubID, capture.WithLabel("rtmp"))
 
 	go func() {
-		defer capturer.UnsubscribeFrames(videoSubID)
+		defer capturer.UnsubscribeFrameRefs(videoSubID)
 		for {
 			select {
 			case <-feedCtx.Done():
@@ -91,26 +113,27 @@ func (m *Manager) StartStream(cameraID, rtmpURL string) error {
 					return
 				}
 				streamer.WriteFrame(frame)
+				frame.Release()
 			}
 		}
 	}()
 
 	// 订阅音频流（如果支持）
 	if capturer.HasAudio() {
-		audioSubID := fmt.Sprintf("rtmp_audio_%s", cameraID)
-		audioCh := capturer.SubscribeAudio(audioSubID)
+		audioSubID := fmt.Sprintf("rtmp_audio_%s_%d", cameraID, time.Now().UnixNano())
+		audioCh := capturer.SubscribeAudioChunks(audioSubID, capture.WithLabel("rtmp"))
 
 		go func() {
-			defer capturer.UnsubscribeAudio(audioSubID)
+			defer capturer.UnsubscribeAudioChunks(audioSubID)
 			for {
 				select {
 				case <-feedCtx.Done():
 					return
-				case audio, ok := <-audioCh:
+				case chunk, ok := <-audioCh:
 					if !ok {
 						return
 					}
-					streamer.WriteAudio(audio)
+					streamer.WriteAudio(chunk.Data)
 				}
 			}
 		}()
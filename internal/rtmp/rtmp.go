@@ -1,7 +1,6 @@
 package rtmp
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
@@ -10,20 +9,23 @@ import (
 	"os/exec"
 	"sync"
 
+	"home-monitor/internal/capture"
 	"home-monitor/internal/config"
+	"home-monitor/internal/ffmpeglog"
 )
 
 // Streamer RTMP 推流器（音视频合并版本）
 type Streamer struct {
 	cameraID  string
 	camConfig config.CameraConfig
+	encoder   config.EncoderProfile
 	rtmpURL   string
 
 	cmd        *exec.Cmd
 	videoStdin io.WriteCloser
 	audioStdin io.WriteCloser
 
-	frameInput chan []byte
+	frameInput chan *capture.Frame
 	audioInput chan []byte
 
 	running bool
@@ -34,12 +36,13 @@ type Streamer struct {
 }
 
 // NewStreamer 创建 RTMP 推流器
-func NewStreamer(cameraID string, camConfig config.CameraConfig, rtmpURL string) *Streamer {
+func NewStreamer(cameraID string, camConfig config.CameraConfig, encoder config.EncoderProfile, rtmpURL string) *Streamer {
 	return &Streamer{
 		cameraID:   cameraID,
 		camConfig:  camConfig,
+		encoder:    encoder,
 		rtmpURL:    rtmpURL,
-		frameInput: make(chan []byte, 30),
+		frameInput: make(chan *capture.Frame, 30),
 		audioInput: make(chan []byte, 100),
 	}
 }
@@ -77,7 +80,7 @@ func (s *Streamer) Start(ctx context.Context) error {
 	args := []string{
 		// 全局选项
 		"-hide_banner",
-		"-loglevel", "warning",
+		"-loglevel", "level+warning",
 
 		// 视频输入 (MJPEG from pipe:3)
 		"-f", "mjpeg",
@@ -89,31 +92,19 @@ func (s *Streamer) Start(ctx context.Context) error {
 		"-ar", "48000",
 		"-ac", "1",
 		"-i", "pipe:4",
+	}
+
+	// 视频编码 (H.264) 与音频编码 (AAC)，参数来自编码档位
+	args = append(args, s.encoder.VideoArgs(s.camConfig.FPS)...)
+	args = append(args, s.encoder.AudioArgs()...)
+	args = append(args, "-ar", "44100")
 
-		// 视频编码 (H.264)
-		"-c:v", "libx264",
-		"-preset", "ultrafast",
-		"-tune", "zerolatency",
-		"-profile:v", "baseline",
-		"-level", "3.1",
-		"-b:v", "2000k",
-		"-maxrate", "2500k",
-		"-bufsize", "4000k",
-		"-g", fmt.Sprintf("%d", s.camConfig.FPS*2),
-		"-keyint_min", fmt.Sprintf("%d", s.camConfig.FPS),
-		"-sc_threshold", "0",
-		"-pix_fmt", "yuv420p",
-
-		// 音频编码 (AAC)
-		"-c:a", "aac",
-		"-b:a", "128k",
-		"-ar", "44100",
-
-		// 输出格式
+	// 输出格式
+	args = append(args,
 		"-f", "flv",
 		"-flvflags", "no_duration_filesize",
 		s.rtmpURL,
-	}
+	)
 
 	log.Printf("启动 RTMP 推流: ffmpeg %v", args)
 
@@ -123,14 +114,7 @@ func (s *Streamer) Start(ctx context.Context) error {
 	// pipe:3 = videoReader, pipe:4 = audioReader
 	s.cmd.ExtraFiles = []*os.File{videoReader, audioReader}
 
-	// 捕获 stderr
-	stderr, _ := s.cmd.StderrPipe()
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			log.Printf("RTMP [%s]: %s", s.cameraID, scanner.Text())
-		}
-	}()
+	s.cmd.Stderr = ffmpeglog.Writer(s.cameraID, ffmpeglog.PipelineRTMP)
 
 	if err := s.cmd.Start(); err != nil {
 		videoReader.Close()
@@ -182,10 +166,12 @@ func (s *Streamer) feedFrames() {
 				return
 			}
 			if !s.IsRunning() {
+				frame.Release()
 				return
 			}
-			if s.videoStdin != nil && len(frame) > 0 {
-				_, err := s.videoStdin.Write(frame)
+			if s.videoStdin != nil && frame.Len() > 0 {
+				_, err := s.videoStdin.Write(frame.Bytes())
+				frame.Release()
 				if err != nil {
 					errCount++
 					if errCount <= 3 {
@@ -201,6 +187,8 @@ func (s *Streamer) feedFrames() {
 				if frameCount == 1 || frameCount%300 == 0 {
 					log.Printf("RTMP 已推送 %d 视频帧: %s", frameCount, s.cameraID)
 				}
+			} else {
+				frame.Release()
 			}
 		}
 	}
@@ -243,15 +231,16 @@ func (s *Streamer) feedAudio() {
 	}
 }
 
-// WriteFrame 写入视频帧
-func (s *Streamer) WriteFrame(frame []byte) {
+// WriteFrame 写入视频帧，入队时持有一个引用，调用方仍需释放自己的引用
+func (s *Streamer) WriteFrame(frame *capture.Frame) {
 	if !s.IsRunning() {
 		return
 	}
 	select {
-	case s.frameInput <- frame:
+	case s.frameInput <- frame.Retain():
 	default:
 		// 缓冲区满，丢弃
+		frame.Release()
 	}
 }
 
@@ -1,7 +1,6 @@
 package stream
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
@@ -10,9 +9,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"home-monitor/internal/capture"
 	"home-monitor/internal/config"
+	"home-monitor/internal/ffmpeglog"
 )
 
 // HLSOutput HLS 输出推流器
@@ -21,6 +22,7 @@ type HLSOutput struct {
 	capturer     capture.AVCapturer
 	camConfig    config.CameraConfig
 	streamConfig config.StreamConfig
+	encoder      config.EncoderProfile
 	outputPath   string
 
 	cmd        *exec.Cmd
@@ -35,11 +37,12 @@ type HLSOutput struct {
 }
 
 // NewHLSOutput 创建 HLS 输出
-func NewHLSOutput(cap capture.AVCapturer, camCfg config.CameraConfig, streamCfg config.StreamConfig, outputPath string) *HLSOutput {
+func NewHLSOutput(cap capture.AVCapturer, camCfg config.CameraConfig, streamCfg config.StreamConfig, encoder config.EncoderProfile, outputPath string) *HLSOutput {
 	return &HLSOutput{
 		capturer:     cap,
 		camConfig:    camCfg,
 		streamConfig: streamCfg,
+		encoder:      encoder,
 		outputPath:   outputPath,
 	}
 }
@@ -92,7 +95,7 @@ func (h *HLSOutput) Start(ctx context.Context) error {
 
 	args := []string{
 		"-hide_banner",
-		"-loglevel", "warning",
+		"-loglevel", "level+warning",
 
 		// 视频输入 (MJPEG from pipe:3)
 		"-f", "mjpeg",
@@ -104,25 +107,14 @@ func (h *HLSOutput) Start(ctx context.Context) error {
 		"-ar", "48000",
 		"-ac", "1",
 		"-i", "pipe:4",
+	}
 
-		// 视频编码 (H.264)
-		"-c:v", "libx264",
-		"-preset", "ultrafast",
-		"-tune", "zerolatency",
-		"-profile:v", "baseline",
-		"-level", "3.1",
-		"-b:v", "1500k",
-		"-maxrate", "2000k",
-		"-bufsize", "3000k",
-		"-g", fmt.Sprintf("%d", h.camConfig.FPS*2),
-		"-sc_threshold", "0",
-		"-pix_fmt", "yuv420p",
-
-		// 音频编码 (AAC)
-		"-c:a", "aac",
-		"-b:a", "128k",
-		"-ar", "44100",
+	// 视频编码 (H.264) 与音频编码 (AAC)，参数来自编码档位
+	args = append(args, h.encoder.VideoArgs(h.camConfig.FPS)...)
+	args = append(args, h.encoder.AudioArgs()...)
+	args = append(args, "-ar", "44100")
 
+	args = append(args,
 		// HLS 输出
 		"-f", "hls",
 		"-hls_time", fmt.Sprintf("%d", segmentDuration),
@@ -130,21 +122,14 @@ func (h *HLSOutput) Start(ctx context.Context) error {
 		"-hls_flags", "delete_segments+append_list",
 		"-hls_segment_filename", filepath.Join(hlsDir, "segment_%03d.ts"),
 		playlistPath,
-	}
+	)
 
-	log.Printf("启动 HLS 输出: %s -> %s", h.capturer.GetID(), playlistPath)
+	log.Printf("启动 HLS 输出: %s -> %s（编码档位 %s）", h.capturer.GetID(), playlistPath, h.encoder.Name)
 
 	h.cmd = exec.CommandContext(h.ctx, "ffmpeg", args...)
 	h.cmd.ExtraFiles = []*os.File{videoReader, audioReader}
 
-	// 捕获 stderr
-	stderr, _ := h.cmd.StderrPipe()
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			log.Printf("HLS [%s]: %s", h.capturer.GetID(), scanner.Text())
-		}
-	}()
+	h.cmd.Stderr = ffmpeglog.Writer(h.capturer.GetID(), ffmpeglog.PipelineHLS)
 
 	if err := h.cmd.Start(); err != nil {
 		videoReader.Close()
@@ -186,41 +171,52 @@ func (h *HLSOutput) Start(ctx context.Context) error {
 
 // feedVideo 发送视频帧
 func (h *HLSOutput) feedVideo() {
-	subID := fmt.Sprintf("hls_video_%s", h.capturer.GetID())
-	frameCh := h.capturer.SubscribeFrames(subID)
-	defer h.capturer.UnsubscribeFrames(subID)
+	subID := fmt.Sprintf("hls_video_%s_%d", h.capturer.GetID(), time.Now().UnixNano())
+	frameCh := h.capturer.SubscribeFrameRefs(subID,
+		capture.WithPolicy(capture.BoundedBlock),
+		capture.WithLabel("hls"),
+	)
+	defer h.capturer.UnsubscribeFrameRefs(subID)
 
 	for {
 		select {
 		case <-h.ctx.Done():
 			return
 		case frame, ok := <-frameCh:
-			if !ok || !h.IsRunning() {
+			if !ok {
+				return
+			}
+			if !h.IsRunning() {
+				frame.Release()
 				return
 			}
-			if h.videoStdin != nil && len(frame) > 0 {
-				h.videoStdin.Write(frame)
+			if h.videoStdin != nil && frame.Len() > 0 {
+				h.videoStdin.Write(frame.Bytes())
 			}
+			frame.Release()
 		}
 	}
 }
 
 // feedAudio 发送音频
 func (h *HLSOutput) feedAudio() {
-	subID := fmt.Sprintf("hls_audio_%s", h.capturer.GetID())
-	audioCh := h.capturer.SubscribeAudio(subID)
-	defer h.capturer.UnsubscribeAudio(subID)
+	subID := fmt.Sprintf("hls_audio_%s_%d", h.capturer.GetID(), time.Now().UnixNano())
+	audioCh := h.capturer.SubscribeAudioChunks(subID,
+		capture.WithPolicy(capture.BoundedBlock),
+		capture.WithLabel("hls"),
+	)
+	defer h.capturer.UnsubscribeAudioChunks(subID)
 
 	for {
 		select {
 		case <-h.ctx.Done():
 			return
-		case audio, ok := <-audioCh:
+		case chunk, ok := <-audioCh:
 			if !ok || !h.IsRunning() {
 				return
 			}
-			if h.audioStdin != nil && len(audio) > 0 {
-				h.audioStdin.Write(audio)
+			if h.audioStdin != nil && len(chunk.Data) > 0 {
+				h.audioStdin.Write(chunk.Data)
 			}
 		}
 	}
@@ -273,31 +269,55 @@ func (h *HLSOutput) GetPlaylistURL() string {
 type HLSOutputManager struct {
 	outputs        map[string]*HLSOutput
 	captureManager *capture.Manager
-	cameras        map[string]config.CameraConfig
 	streamConfig   config.StreamConfig
+	encoders       config.EncoderProfiles
 	outputPath     string
 	mutex          sync.RWMutex
 	ctx            context.Context
+
+	// 采集器重启期间暂停、重启后需恢复的输出
+	resume map[string]bool
 }
 
 // NewHLSOutputManager 创建 HLS 输出管理器
-func NewHLSOutputManager(ctx context.Context, capManager *capture.Manager, cameras []config.CameraConfig, streamCfg config.StreamConfig) *HLSOutputManager {
+func NewHLSOutputManager(ctx context.Context, capManager *capture.Manager, streamCfg config.StreamConfig, encoders config.EncoderProfiles) *HLSOutputManager {
 	m := &HLSOutputManager{
 		outputs:        make(map[string]*HLSOutput),
 		captureManager: capManager,
-		cameras:        make(map[string]config.CameraConfig),
 		streamConfig:   streamCfg,
+		encoders:       encoders,
 		outputPath:     filepath.Join(streamCfg.TempPath, "hls"),
 		ctx:            ctx,
+		resume:         make(map[string]bool),
 	}
 
-	for _, cam := range cameras {
-		if cam.Enabled {
-			m.cameras[cam.ID] = cam
+	capManager.AddListener(m.handleCaptureEvent)
+	return m
+}
+
+// handleCaptureEvent 采集器停止时拆除 HLS 输出，重启完成后恢复
+func (m *HLSOutputManager) handleCaptureEvent(event capture.Event) {
+	switch event.Type {
+	case capture.EventStopping:
+		running, _ := m.GetOutputStatus(event.CameraID)
+		m.StopOutput(event.CameraID)
+		if running && event.Restarting {
+			m.mutex.Lock()
+			m.resume[event.CameraID] = true
+			m.mutex.Unlock()
+		}
+	case capture.EventStarted:
+		m.mutex.Lock()
+		resume := m.resume[event.CameraID]
+		delete(m.resume, event.CameraID)
+		m.mutex.Unlock()
+
+		if resume {
+			if err := m.StartOutput(event.CameraID); err != nil {
+				log.Printf("恢复 HLS 输出 %s 失败: %v", event.CameraID, err)
+			}
 		}
 	}
-
-	return m
 }
 
 // StartOutput 启动指定摄像头的 HLS 输出
@@ -309,17 +329,14 @@ func (m *HLSOutputManager) StartOutput(cameraID string) error {
 		return fmt.Errorf("HLS 输出已在运行: %s", cameraID)
 	}
 
-	camCfg, exists := m.cameras[cameraID]
-	if !exists {
-		return fmt.Errorf("摄像头不存在: %s", cameraID)
-	}
-
 	capturer, err := m.captureManager.GetCapturer(cameraID)
 	if err != nil {
 		return fmt.Errorf("获取采集器失败: %w", err)
 	}
 
-	output := NewHLSOutput(capturer, camCfg, m.streamConfig, m.outputPath)
+	camCfg := capturer.GetConfig()
+	encoder := m.encoders.Resolve(camCfg.Encoders.HLS, config.EncoderHLS)
+	output := NewHLSOutput(capturer, camCfg, m.streamConfig, encoder, m.outputPath)
 	if err := output.Start(m.ctx); err != nil {
 		return err
 	}
@@ -14,20 +14,17 @@ import (
 )
 
 // HLSStreamer HLS 流处理器
-// 负责 MJPEG 帧分发（用于 Web 预览）
+// 负责 MJPEG 帧分发（用于 Web 预览），订阅直接挂在采集器上，由采集器统一执行背压策略和统计
 type HLSStreamer struct {
 	capturer   capture.AVCapturer
 	config     config.StreamConfig
 	outputPath string
 	running    bool
 	mutex      sync.RWMutex
-	ctx        context.Context
-	cancel     context.CancelFunc
-	done       chan struct{}
 
-	// MJPEG 订阅者（用于 Web 预览）
-	subscribers     map[string]chan []byte
-	subscriberMutex sync.RWMutex
+	// MJPEG 订阅（用于 Web 预览），记录订阅 ID 以便停止时统一取消
+	subscribers     map[string]struct{}
+	subscriberMutex sync.Mutex
 }
 
 // NewHLSStreamer 创建 HLS 流处理器
@@ -35,22 +32,18 @@ func NewHLSStreamer(cap capture.AVCapturer, streamCfg config.StreamConfig) *HLSS
 	return &HLSStreamer{
 		capturer:    cap,
 		config:      streamCfg,
-		subscribers: make(map[string]chan []byte),
-		done:        make(chan struct{}),
+		subscribers: make(map[string]struct{}),
 	}
 }
 
 // Start 启动流处理器
 func (s *HLSStreamer) Start(ctx context.Context) error {
 	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	if s.running {
-		s.mutex.Unlock()
 		return nil
 	}
-	s.mutex.Unlock()
-
-	s.ctx, s.cancel = context.WithCancel(context.Background())
-	s.done = make(chan struct{})
 
 	// 创建 HLS 输出目录（即使不用 HLS 也保留目录结构）
 	s.outputPath = filepath.Join(s.config.TempPath, "hls", s.capturer.GetID())
@@ -58,13 +51,7 @@ func (s *HLSStreamer) Start(ctx context.Context) error {
 		return fmt.Errorf("创建输出目录失败: %w", err)
 	}
 
-	s.mutex.Lock()
 	s.running = true
-	s.mutex.Unlock()
-
-	// 启动 MJPEG 帧分发（用于 Web 预览）
-	go s.distributeFrames()
-
 	log.Printf("HLS 流 %s 已启动", s.capturer.GetID())
 	return nil
 }
@@ -76,41 +63,34 @@ func (s *HLSStreamer) Stop() error {
 		s.mutex.Unlock()
 		return nil
 	}
+	s.running = false
 	s.mutex.Unlock()
 
-	if s.cancel != nil {
-		s.cancel()
-	}
-
-	select {
-	case <-s.done:
-	case <-time.After(5 * time.Second):
-	}
-
-	// 关闭订阅者
+	// 取消所有订阅
 	s.subscriberMutex.Lock()
-	for id, ch := range s.subscribers {
-		close(ch)
+	for id := range s.subscribers {
+		s.capturer.UnsubscribeFrameRefs(s.subscriptionID(id))
 		delete(s.subscribers, id)
 	}
 	s.subscriberMutex.Unlock()
 
-	s.mutex.Lock()
-	s.running = false
-	s.mutex.Unlock()
-
 	log.Printf("HLS 流 %s 已停止", s.capturer.GetID())
 	return nil
 }
 
-// Subscribe 订阅 MJPEG 视频流（用于 Web 预览）
-func (s *HLSStreamer) Subscribe(id string) <-chan []byte {
+// Subscribe 订阅 MJPEG 视频流（用于 Web 预览），收到的帧使用完毕后需调用 Release
+// 默认缓冲 10 帧、丢弃新帧，可通过 opts 覆盖
+func (s *HLSStreamer) Subscribe(id string, opts ...capture.SubscribeOption) <-chan *capture.Frame {
 	s.subscriberMutex.Lock()
 	defer s.subscriberMutex.Unlock()
 
-	ch := make(chan []byte, 10)
-	s.subscribers[id] = ch
-	return ch
+	defaults := []capture.SubscribeOption{
+		capture.WithPolicy(capture.DropNewest),
+		capture.WithBufferSize(10),
+		capture.WithLabel("stream " + id),
+	}
+	s.subscribers[id] = struct{}{}
+	return s.capturer.SubscribeFrameRefs(s.subscriptionID(id), append(defaults, opts...)...)
 }
 
 // Unsubscribe 取消订阅
@@ -118,12 +98,17 @@ func (s *HLSStreamer) Unsubscribe(id string) {
 	s.subscriberMutex.Lock()
 	defer s.subscriberMutex.Unlock()
 
-	if ch, exists := s.subscribers[id]; exists {
-		close(ch)
+	if _, exists := s.subscribers[id]; exists {
+		s.capturer.UnsubscribeFrameRefs(s.subscriptionID(id))
 		delete(s.subscribers, id)
 	}
 }
 
+// subscriptionID 采集器上的订阅 ID
+func (s *HLSStreamer) subscriptionID(id string) string {
+	return fmt.Sprintf("stream_preview_%s_%s", s.capturer.GetID(), id)
+}
+
 // GetPlaylistPath 获取 HLS 播放列表路径
 func (s *HLSStreamer) GetPlaylistPath() string {
 	return filepath.Join(s.outputPath, "playlist.m3u8")
@@ -136,43 +121,6 @@ func (s *HLSStreamer) IsRunning() bool {
 	return s.running
 }
 
-// distributeFrames 分发 MJPEG 帧到 Web 预览订阅者
-func (s *HLSStreamer) distributeFrames() {
-	defer func() {
-		select {
-		case <-s.done:
-		default:
-			close(s.done)
-		}
-	}()
-
-	subID := fmt.Sprintf("stream_preview_%s_%d", s.capturer.GetID(), time.Now().UnixNano())
-	frameChannel := s.capturer.SubscribeFrames(subID)
-	defer s.capturer.UnsubscribeFrames(subID)
-
-	for {
-		select {
-		case <-s.ctx.Done():
-			return
-		case frame, ok := <-frameChannel:
-			if !ok {
-				return
-			}
-
-			// 分发帧到所有订阅者
-			s.subscriberMutex.RLock()
-			for _, ch := range s.subscribers {
-				select {
-				case ch <- frame:
-				default:
-					// 通道满了，跳过
-				}
-			}
-			s.subscriberMutex.RUnlock()
-		}
-	}
-}
-
 // StreamManager 流管理器
 type StreamManager struct {
 	streamers      map[string]*HLSStreamer
@@ -183,11 +131,31 @@ type StreamManager struct {
 
 // NewStreamManager 创建流管理器
 func NewStreamManager(capManager *capture.Manager, streamCfg config.StreamConfig) *StreamManager {
-	return &StreamManager{
+	m := &StreamManager{
 		streamers:      make(map[string]*HLSStreamer),
 		captureManager: capManager,
 		streamConfig:   streamCfg,
 	}
+	capManager.AddListener(m.handleCaptureEvent)
+	return m
+}
+
+// handleCaptureEvent 采集器启停时同步启停预览分发
+func (m *StreamManager) handleCaptureEvent(event capture.Event) {
+	switch event.Type {
+	case capture.EventStopping:
+		// 流处理器持有采集器引用，停止后移除，启动时按当前采集器重新创建
+		m.mutex.Lock()
+		if streamer, exists := m.streamers[event.CameraID]; exists {
+			streamer.Stop()
+			delete(m.streamers, event.CameraID)
+		}
+		m.mutex.Unlock()
+	case capture.EventStarted:
+		if err := m.StartStream(context.Background(), event.CameraID); err != nil {
+			log.Printf("恢复流 %s 失败: %v", event.CameraID, err)
+		}
+	}
 }
 
 // CreateStream 创建流
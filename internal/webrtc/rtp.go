@@ -1,7 +1,6 @@
 package webrtc
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
@@ -14,7 +13,9 @@ import (
 	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v3"
 
+	"home-monitor/internal/capture"
 	"home-monitor/internal/config"
+	"home-monitor/internal/ffmpeglog"
 )
 
 // RTPForwarder RTP 转发器 - 从 JPEG 帧编码为 VP8/Opus RTP 流
@@ -43,7 +44,7 @@ type RTPForwarder struct {
 	audioTrack *webrtc.TrackLocalStaticRTP
 
 	// JPEG 帧输入
-	frameInput chan []byte
+	frameInput chan *capture.Frame
 
 	// PCM 音频输入
 	audioInput chan []byte
@@ -69,7 +70,7 @@ func NewRTPForwarder(cameraID string, camConfig config.CameraConfig, videoPort,
 		camConfig:  camConfig,
 		videoPort:  videoPort,
 		audioPort:  audioPort,
-		frameInput: make(chan []byte, 10),
+		frameInput: make(chan *capture.Frame, 10),
 		audioInput: make(chan []byte, 100),
 		hasAudio:   camConfig.Audio.Enabled,
 	}
@@ -166,6 +167,10 @@ func (f *RTPForwarder) startVideoEncoder() error {
 	defer f.videoCmdMutex.Unlock()
 
 	args := []string{
+		"-hide_banner",
+		"-nostats",
+		"-loglevel", "level+warning",
+
 		// 输入: 使用 mjpeg 格式（连续 JPEG 流）
 		"-f", "mjpeg",
 		"-framerate", fmt.Sprintf("%d", f.camConfig.FPS),
@@ -193,14 +198,7 @@ func (f *RTPForwarder) startVideoEncoder() error {
 		return fmt.Errorf("创建 stdin 管道失败: %w", err)
 	}
 
-	// 捕获 stderr 用于调试
-	stderr, _ := f.videoCmd.StderrPipe()
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			log.Printf("FFmpeg VP8 [%s]: %s", f.cameraID, scanner.Text())
-		}
-	}()
+	f.videoCmd.Stderr = ffmpeglog.Writer(f.cameraID, ffmpeglog.PipelineWebRTCVideo)
 
 	if err := f.videoCmd.Start(); err != nil {
 		return fmt.Errorf("启动 FFmpeg 视频编码器失败: %w", err)
@@ -222,6 +220,10 @@ func (f *RTPForwarder) startAudioEncoder() error {
 	defer f.audioCmdMutex.Unlock()
 
 	args := []string{
+		"-hide_banner",
+		"-nostats",
+		"-loglevel", "level+warning",
+
 		// 输入: PCM S16LE 48kHz mono
 		"-f", "s16le",
 		"-ar", "48000",
@@ -247,14 +249,7 @@ func (f *RTPForwarder) startAudioEncoder() error {
 		return fmt.Errorf("创建音频 stdin 管道失败: %w", err)
 	}
 
-	// 捕获 stderr
-	stderr, _ := f.audioCmd.StderrPipe()
-	go func() {
-		scanner := bufio.NewScanner(stderr)
-		for scanner.Scan() {
-			log.Printf("FFmpeg Opus [%s]: %s", f.cameraID, scanner.Text())
-		}
-	}()
+	f.audioCmd.Stderr = ffmpeglog.Writer(f.cameraID, ffmpeglog.PipelineWebRTCAudio)
 
 	if err := f.audioCmd.Start(); err != nil {
 		return fmt.Errorf("启动 FFmpeg 音频编码器失败: %w", err)
@@ -281,16 +276,20 @@ func (f *RTPForwarder) feedFrames() {
 			if !ok {
 				return
 			}
-			if f.videoStdin != nil && len(frame) > 0 {
-				n, err := f.videoStdin.Write(frame)
+			if f.videoStdin != nil && frame.Len() > 0 {
+				n, err := f.videoStdin.Write(frame.Bytes())
+				size := frame.Len()
+				frame.Release()
 				if err != nil {
 					log.Printf("写入帧到编码器失败: %v", err)
 					continue
 				}
 				frameCount++
 				if frameCount == 1 || frameCount%100 == 0 {
-					log.Printf("已写入 %d 帧到 VP8 编码器 (当前帧大小: %d bytes, 写入: %d)", frameCount, len(frame), n)
+					log.Printf("已写入 %d 帧到 VP8 编码器 (当前帧大小: %d bytes, 写入: %d)", frameCount, size, n)
 				}
+			} else {
+				frame.Release()
 			}
 		}
 	}
@@ -334,8 +333,10 @@ func (f *RTPForwarder) WriteAudio(audio []byte) {
 	}
 }
 
-// WriteFrame 写入 JPEG 帧
-func (f *RTPForwarder) WriteFrame(frame []byte) {
+// WriteFrame 写入 JPEG 帧，入队时持有一个引用，调用方仍需释放自己的引用
+func (f *RTPForwarder) WriteFrame(ref *capture.Frame) {
+	frame := ref.Bytes()
+
 	// 验证 JPEG 数据
 	if len(frame) < 2 {
 		log.Printf("WriteFrame: 帧太小 (%d bytes)", len(frame))
@@ -357,9 +358,10 @@ func (f *RTPForwarder) WriteFrame(frame []byte) {
 	}
 
 	select {
-	case f.frameInput <- frame:
+	case f.frameInput <- ref.Retain():
 	default:
 		// 缓冲区满，丢弃
+		ref.Release()
 	}
 }
 
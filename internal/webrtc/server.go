@@ -11,7 +11,6 @@ import (
 	"github.com/pion/webrtc/v3"
 
 	"home-monitor/internal/capture"
-	"home-monitor/internal/config"
 )
 
 // PeerConnection WebRTC 连接
@@ -25,7 +24,6 @@ type PeerConnection struct {
 // Server WebRTC 服务器（使用 RTP 转发）
 type Server struct {
 	captureManager *capture.Manager
-	cameras        map[string]config.CameraConfig
 	forwarders     map[string]*RTPForwarder
 	connections    map[string]*PeerConnection
 	frameFeeds     map[string]context.CancelFunc // 帧订阅的取消函数
@@ -44,7 +42,7 @@ type Server struct {
 }
 
 // NewServer 创建 WebRTC 服务器
-func NewServer(captureManager *capture.Manager, cameras []config.CameraConfig, stunServers []string) *Server {
+func NewServer(captureManager *capture.Manager, stunServers []string) *Server {
 	if len(stunServers) == 0 {
 		stunServers = []string{
 			"stun:stun.l.google.com:19302",
@@ -62,7 +60,6 @@ func NewServer(captureManager *capture.Manager, cameras []config.CameraConfig, s
 
 	s := &Server{
 		captureManager: captureManager,
-		cameras:        make(map[string]config.CameraConfig),
 		forwarders:     make(map[string]*RTPForwarder),
 		connections:    make(map[string]*PeerConnection),
 		frameFeeds:     make(map[string]context.CancelFunc),
@@ -73,13 +70,7 @@ func NewServer(captureManager *capture.Manager, cameras []config.CameraConfig, s
 		cancel:         cancel,
 	}
 
-	// 保存摄像头配置
-	for _, cam := range cameras {
-		if cam.Enabled {
-			s.cameras[cam.ID] = cam
-		}
-	}
-
+	captureManager.AddListener(s.handleCaptureEvent)
 	return s
 }
 
@@ -114,22 +105,20 @@ func (s *Server) getOrCreateForwarder(ctx context.Context, cameraID string) (*RT
 		delete(s.forwarders, cameraID)
 	}
 
-	// 获取摄像头配置
-	camConfig, exists := s.cameras[cameraID]
-	if !exists {
-		return nil, fmt.Errorf("摄像头不存在: %s", cameraID)
-	}
-
 	// 获取采集器
 	capturer, err := s.captureManager.GetCapturer(cameraID)
 	if err != nil {
 		return nil, fmt.Errorf("获取采集器失败: %w", err)
 	}
+	camConfig := capturer.GetConfig()
 
 	if !capturer.IsRunning() {
 		return nil, fmt.Errorf("采集器未运行: %s", cameraID)
 	}
 
+	// 以采集器实际能力为准（如 HTTP 预览源不含音频）
+	camConfig.Audio.Enabled = capturer.HasAudio()
+
 	// 分配端口
 	videoPort, audioPort := s.allocatePorts()
 
@@ -142,14 +131,22 @@ func (s *Server) getOrCreateForwarder(ctx context.Context, cameraID string) (*RT
 	}
 
 	// 订阅帧流并喂给转发器
-	feedCtx, feedCancel := context.WithCancel(ctx)
+	s.attachFeeds(cameraID, capturer, fwd)
+
+	s.forwarders[cameraID] = fwd
+	return fwd, nil
+}
+
+// attachFeeds 订阅采集器的帧/音频并喂给转发器（调用方需持有 s.mutex）
+func (s *Server) attachFeeds(cameraID string, capturer capture.AVCapturer, fwd *RTPForwarder) {
+	feedCtx, feedCancel := context.WithCancel(s.ctx)
 	s.frameFeeds[cameraID] = feedCancel
 
 	subID := fmt.Sprintf("webrtc_%s_%d", cameraID, time.Now().UnixNano())
-	frameCh := capturer.SubscribeFrames(subID)
+	frameCh := capturer.SubscribeFrameRefs(subID, capture.WithLabel("webrtc"))
 
 	go func() {
-		defer capturer.UnsubscribeFrames(subID)
+		defer capturer.UnsubscribeFrameRefs(subID)
 		frameCount := 0
 		for {
 			select {
@@ -163,42 +160,99 @@ func (s *Server) getOrCreateForwarder(ctx context.Context, cameraID string) (*RT
 				}
 				frameCount++
 				if frameCount <= 5 || frameCount%100 == 0 {
-					log.Printf("收到帧 #%d (%d bytes)，发送到转发器: %s", frameCount, len(frame), cameraID)
+					log.Printf("收到帧 #%d (%d bytes)，发送到转发器: %s", frameCount, frame.Len(), cameraID)
 				}
 				fwd.WriteFrame(frame)
+				frame.Release()
 			}
 		}
 	}()
 
 	// 如果启用音频，也订阅音频流
-	if camConfig.Audio.Enabled {
-		audioCh := capturer.SubscribeAudio(subID + "_audio")
+	if capturer.HasAudio() {
+		audioCh := capturer.SubscribeAudioChunks(subID+"_audio", capture.WithLabel("webrtc"))
 
 		go func() {
-			defer capturer.UnsubscribeAudio(subID + "_audio")
+			defer capturer.UnsubscribeAudioChunks(subID + "_audio")
 			audioCount := 0
 			for {
 				select {
 				case <-feedCtx.Done():
 					log.Printf("音频订阅已取消: %s", cameraID)
 					return
-				case audio, ok := <-audioCh:
+				case chunk, ok := <-audioCh:
 					if !ok {
 						log.Printf("音频通道已关闭: %s", cameraID)
 						return
 					}
 					audioCount++
 					if audioCount == 1 || audioCount%500 == 0 {
-						log.Printf("收到音频帧 #%d (%d bytes)，发送到转发器: %s", audioCount, len(audio), cameraID)
+						log.Printf("收到音频帧 #%d (%d bytes)，发送到转发器: %s", audioCount, len(chunk.Data), cameraID)
 					}
-					fwd.WriteAudio(audio)
+					fwd.WriteAudio(chunk.Data)
 				}
 			}
 		}()
 	}
+}
 
-	s.forwarders[cameraID] = fwd
-	return fwd, nil
+// handleCaptureEvent 采集器启停时拆除或重新挂接转发器
+// 重启时保留转发器和已建立的连接，只重新订阅帧流；停止或配置变更时关闭该摄像头的所有连接
+func (s *Server) handleCaptureEvent(event capture.Event) {
+	switch event.Type {
+	case capture.EventStopping:
+		s.mutex.Lock()
+		if cancelFn, ok := s.frameFeeds[event.CameraID]; ok {
+			cancelFn()
+			delete(s.frameFeeds, event.CameraID)
+		}
+		s.mutex.Unlock()
+
+		// 普通重启保留转发器；配置变更后编码参数可能失效，需要重建
+		if event.Restarting && !event.ConfigChanged {
+			return
+		}
+		s.closeCamera(event.CameraID)
+
+	case capture.EventStarted:
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		fwd, exists := s.forwarders[event.CameraID]
+		if !exists || !fwd.IsRunning() {
+			return
+		}
+		capturer, err := s.captureManager.GetCapturer(event.CameraID)
+		if err != nil {
+			return
+		}
+		s.attachFeeds(event.CameraID, capturer, fwd)
+		log.Printf("RTP 转发器已重新挂接: %s", event.CameraID)
+	}
+}
+
+// closeCamera 关闭指定摄像头的所有连接并停止其转发器
+func (s *Server) closeCamera(cameraID string) {
+	s.mutex.RLock()
+	var connIDs []string
+	for id, conn := range s.connections {
+		if conn.CameraID == cameraID {
+			connIDs = append(connIDs, id)
+		}
+	}
+	s.mutex.RUnlock()
+
+	for _, id := range connIDs {
+		s.CloseConnection(id)
+	}
+
+	// 没有连接的转发器也一并停止
+	s.mutex.Lock()
+	if fwd, exists := s.forwarders[cameraID]; exists {
+		fwd.Stop()
+		delete(s.forwarders, cameraID)
+	}
+	s.mutex.Unlock()
 }
 
 // OfferRequest SDP Offer 请求